@@ -0,0 +1,146 @@
+package main
+
+import (
+    "errors"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+// NOTE: Every permission check go through here, dont read claims["admin"]
+//       or EventPRole on the handler anymore.
+//
+//       super-admin : everything, the only one that can hand out admin role.
+//       admin       : everything on every user and event.
+//       organizer   : can make new event and manage the event they are committee of.
+//       user        : join event, manage an event only if committee of it.
+//
+//       The role is read from the session row (loaded by sessionCheck), not
+//       from the jwt, so a role change apply on the next request.
+
+var (
+    errNotLoggedIn = errors.New("not logged in")
+    errForbidden   = errors.New("not allowed to access this")
+)
+
+func roleRank(role table.UserRoleEnum) int {
+    switch role {
+    case table.RoleSuperAdmin:
+        return 3
+    case table.RoleAdmin:
+        return 2
+    case table.RoleOrganizer:
+        return 1
+    }
+    return 0
+}
+
+func roleAtLeast(role table.UserRoleEnum, min table.UserRoleEnum) bool {
+    return roleRank(role) >= roleRank(min)
+}
+
+func isValidRole(role table.UserRoleEnum) bool {
+    switch role {
+    case table.RoleUser, table.RoleAdmin, table.RoleOrganizer, table.RoleSuperAdmin:
+        return true
+    }
+    return false
+}
+
+// Admin can give user and organizer, only super-admin can give admin and super-admin.
+func canGrantRole(actor table.UserRoleEnum, role table.UserRoleEnum) bool {
+    if !isValidRole(role) || !roleAtLeast(actor, table.RoleAdmin) {
+        return false
+    }
+    if roleAtLeast(role, table.RoleAdmin) {
+        return actor == table.RoleSuperAdmin
+    }
+    return true
+}
+
+// Admin can only edit or delete the one below them, super-admin can touch anyone.
+func canManageUser(actor table.UserRoleEnum, target table.UserRoleEnum) bool {
+    if actor == table.RoleSuperAdmin {
+        return true
+    }
+    return roleAtLeast(actor, table.RoleAdmin) && !roleAtLeast(target, table.RoleAdmin)
+}
+
+// NOTE: part is nil when the user did not join the event. Committee can do
+//       everything a normal participant can.
+func eventRoleAllows(role table.UserRoleEnum, part *table.EventParticipant, need table.UserEventRoleEnum) bool {
+    if roleAtLeast(role, table.RoleAdmin) {
+        return true
+    }
+    if part == nil {
+        return false
+    }
+    if need == table.CommitteeU {
+        return part.EventPRole == table.CommitteeU
+    }
+    return true
+}
+
+// Only valid behind the protected or cookieJWT group.
+func currentUser(c *fiber.Ctx) (*table.User, error) {
+    session, err := currentSession(c)
+    if err != nil || session.User.ID == 0 {
+        return nil, errNotLoggedIn
+    }
+    return &session.User, nil
+}
+
+func requireRole(c *fiber.Ctx, min table.UserRoleEnum) (*table.User, error) {
+    user, err := currentUser(c)
+    if err != nil {
+        return nil, err
+    }
+    if !roleAtLeast(user.UserRole, min) {
+        return user, errForbidden
+    }
+    return user, nil
+}
+
+// Return nil without error when the user did not join the event.
+func eventParticipantOf(backend *Backend, userId int, eventId int) (*table.EventParticipant, error) {
+    var part table.EventParticipant
+    res := backend.db.Where("event_id = ? AND user_id = ?", eventId, userId).First(&part)
+    if res.Error != nil {
+        if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, res.Error
+    }
+    return &part, nil
+}
+
+// NOTE: The error is errNotLoggedIn, errForbidden or a db error, the user is
+//       still returned on errForbidden so the caller can use it for the message.
+func requireEventRole(backend *Backend, c *fiber.Ctx, eventId int, need table.UserEventRoleEnum) (*table.User, error) {
+    user, err := currentUser(c)
+    if err != nil {
+        return nil, err
+    }
+    if roleAtLeast(user.UserRole, table.RoleAdmin) {
+        return user, nil
+    }
+
+    part, err := eventParticipantOf(backend, user.ID, eventId)
+    if err != nil {
+        return user, err
+    }
+    if !eventRoleAllows(user.UserRole, part, need) {
+        return user, errForbidden
+    }
+    return user, nil
+}
+
+// Map the error from requireRole and requireEventRole to the http status.
+func authzStatus(err error) int {
+    switch {
+    case errors.Is(err, errNotLoggedIn), errors.Is(err, errForbidden):
+        return fiber.StatusUnauthorized
+    }
+    return fiber.StatusInternalServerError
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+    "testing"
+    "webrpl/table"
+)
+
+func TestRoleAtLeast(t *testing.T) {
+    tests := []struct {
+        role table.UserRoleEnum
+        min  table.UserRoleEnum
+        want bool
+    }{
+        {table.RoleUser, table.RoleUser, true},
+        {table.RoleUser, table.RoleOrganizer, false},
+        {table.RoleUser, table.RoleAdmin, false},
+        {table.RoleOrganizer, table.RoleUser, true},
+        {table.RoleOrganizer, table.RoleOrganizer, true},
+        {table.RoleOrganizer, table.RoleAdmin, false},
+        // Admin is 1 and organizer is 2, the number is not the rank.
+        {table.RoleAdmin, table.RoleOrganizer, true},
+        {table.RoleAdmin, table.RoleAdmin, true},
+        {table.RoleAdmin, table.RoleSuperAdmin, false},
+        {table.RoleSuperAdmin, table.RoleAdmin, true},
+        {table.RoleSuperAdmin, table.RoleSuperAdmin, true},
+        // An unknown role is only a user.
+        {table.UserRoleEnum(9), table.RoleUser, true},
+        {table.UserRoleEnum(9), table.RoleOrganizer, false},
+    }
+    for _, tt := range tests {
+        if got := roleAtLeast(tt.role, tt.min); got != tt.want {
+            t.Errorf("roleAtLeast(%d, %d) = %v, want %v", tt.role, tt.min, got, tt.want)
+        }
+    }
+}
+
+func TestCanGrantRole(t *testing.T) {
+    tests := []struct {
+        actor table.UserRoleEnum
+        role  table.UserRoleEnum
+        want  bool
+    }{
+        {table.RoleUser, table.RoleUser, false},
+        {table.RoleOrganizer, table.RoleUser, false},
+        {table.RoleOrganizer, table.RoleOrganizer, false},
+        {table.RoleAdmin, table.RoleUser, true},
+        {table.RoleAdmin, table.RoleOrganizer, true},
+        {table.RoleAdmin, table.RoleAdmin, false},
+        {table.RoleAdmin, table.RoleSuperAdmin, false},
+        {table.RoleSuperAdmin, table.RoleUser, true},
+        {table.RoleSuperAdmin, table.RoleOrganizer, true},
+        {table.RoleSuperAdmin, table.RoleAdmin, true},
+        {table.RoleSuperAdmin, table.RoleSuperAdmin, true},
+        {table.RoleSuperAdmin, table.UserRoleEnum(9), false},
+        {table.RoleSuperAdmin, table.UserRoleEnum(-1), false},
+    }
+    for _, tt := range tests {
+        if got := canGrantRole(tt.actor, tt.role); got != tt.want {
+            t.Errorf("canGrantRole(%d, %d) = %v, want %v", tt.actor, tt.role, got, tt.want)
+        }
+    }
+}
+
+func TestCanManageUser(t *testing.T) {
+    tests := []struct {
+        actor  table.UserRoleEnum
+        target table.UserRoleEnum
+        want   bool
+    }{
+        {table.RoleUser, table.RoleUser, false},
+        {table.RoleOrganizer, table.RoleUser, false},
+        {table.RoleAdmin, table.RoleUser, true},
+        {table.RoleAdmin, table.RoleOrganizer, true},
+        {table.RoleAdmin, table.RoleAdmin, false},
+        {table.RoleAdmin, table.RoleSuperAdmin, false},
+        {table.RoleSuperAdmin, table.RoleUser, true},
+        {table.RoleSuperAdmin, table.RoleAdmin, true},
+        {table.RoleSuperAdmin, table.RoleSuperAdmin, true},
+    }
+    for _, tt := range tests {
+        if got := canManageUser(tt.actor, tt.target); got != tt.want {
+            t.Errorf("canManageUser(%d, %d) = %v, want %v", tt.actor, tt.target, got, tt.want)
+        }
+    }
+}
+
+func TestEventRoleAllows(t *testing.T) {
+    normal := &table.EventParticipant{EventPRole: table.NormalU}
+    committee := &table.EventParticipant{EventPRole: table.CommitteeU}
+
+    tests := []struct {
+        name string
+        role table.UserRoleEnum
+        part *table.EventParticipant
+        need table.UserEventRoleEnum
+        want bool
+    }{
+        {"user not joined", table.RoleUser, nil, table.NormalU, false},
+        {"user not joined need committee", table.RoleUser, nil, table.CommitteeU, false},
+        {"normal participant", table.RoleUser, normal, table.NormalU, true},
+        {"normal participant need committee", table.RoleUser, normal, table.CommitteeU, false},
+        {"committee as normal", table.RoleUser, committee, table.NormalU, true},
+        {"committee", table.RoleUser, committee, table.CommitteeU, true},
+        {"organizer not joined", table.RoleOrganizer, nil, table.CommitteeU, false},
+        {"organizer committee", table.RoleOrganizer, committee, table.CommitteeU, true},
+        {"admin not joined", table.RoleAdmin, nil, table.CommitteeU, true},
+        {"super-admin not joined", table.RoleSuperAdmin, nil, table.CommitteeU, true},
+    }
+    for _, tt := range tests {
+        if got := eventRoleAllows(tt.role, tt.part, tt.need); got != tt.want {
+            t.Errorf("%s: eventRoleAllows = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "html"
+    "io"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    texttemplate "text/template"
+    "webrpl/table"
+
+    "github.com/go-pdf/fpdf"
+    "gorm.io/gorm"
+)
+
+var errCertTemplateMissing = errors.New("certificate template file does not exist")
+
+// NOTE: Where the template of an event live, shared by the html and pdf
+//       certificate. The editor save both file on StaticDir/<event id>/.
+type certTemplateFiles struct {
+    Template table.CertTemplate
+    HTMLPath string
+    BGPath   string
+    // Name for c.Render, the template path without `.html`.
+    Name     string
+}
+
+// Only participant that came get a certificate. The code from before the
+// migration 0007 still work here, but not for the attendance.
+func findCertParticipant(backend *Backend, code string) (*table.EventParticipant, error) {
+    if code == "" {
+        return nil, gorm.ErrRecordNotFound
+    }
+
+    var evPart table.EventParticipant
+    res := backend.db.Preload("User").Preload("Event").
+        Where("(eventp_code = ? OR eventp_legacy_code = ?) AND eventp_come = ?", code, code, true).
+        First(&evPart)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+    return &evPart, nil
+}
+
+// The error is a db error (gorm.ErrRecordNotFound when the event have no
+// template) or errCertTemplateMissing when the row exist but the file dont.
+func resolveCertTemplate(backend *Backend, eventId int) (*certTemplateFiles, error) {
+    var cerTemp table.CertTemplate
+    res := backend.db.Where("event_id = ?", eventId).First(&cerTemp)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+
+    files := newCertTemplateFiles(backend, cerTemp, cerTemp.CertTemplate)
+    if _, err := os.Stat(files.HTMLPath); err != nil {
+        return files, fmt.Errorf("%w, %s", errCertTemplateMissing, files.HTMLPath)
+    }
+    return files, nil
+}
+
+// path is relative to the static dir, like CertTemplate.CertTemplate.
+func newCertTemplateFiles(backend *Backend, cerTemp table.CertTemplate, path string) *certTemplateFiles {
+    files := &certTemplateFiles{
+        Template: cerTemp,
+        HTMLPath: filepath.Join(backend.config.Upload.StaticDir, path),
+        Name:     strings.TrimSuffix(path, ".html"),
+    }
+    files.BGPath = filepath.Join(filepath.Dir(files.HTMLPath), "bg.png")
+    return files
+}
+
+// What the template get, the key is the `{{ .Key }}` on the html. See
+// certPlaceholders, the date are certDate so the helper can format them.
+func certificateData(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate) map[string]any {
+    locale := backend.config.Locale
+    return map[string]any{
+        "UniqueID":     evPart.EventPCode,
+        "Serial":       cert.CertSerial,
+        "IssuedAt":     certDate{Time: cert.CertIssuedAt, Locale: locale},
+        "EventName":    evPart.Event.EventName,
+        "EventSpeaker": evPart.Event.EventSpeaker,
+        "EventStart":   certDate{Time: evPart.Event.EventDStart, Locale: locale},
+        "EventEnd":     certDate{Time: evPart.Event.EventDEnd, Locale: locale},
+        "UserName":     evPart.User.UserFullName,
+        "UserInstance": evPart.User.UserInstance,
+        "VerifyURL":    certificateVerifyURL(backend, evPart, cert),
+        "VerifyQR":     certificateQRURL(backend, evPart, cert),
+    }
+}
+
+// NOTE: One text box of the template, the unit is the css px of the editor.
+type certField struct {
+    Text   string
+    QR     bool
+    X, Y   float64
+    W, H   float64
+    Color  [3]int
+    Size   float64
+    Bold   bool
+    Italic bool
+}
+
+var (
+    certCanvasRe = regexp.MustCompile(`(?s)\.template-canvas\s*\{[^}]*?width:\s*([\d.]+)px;[^}]*?height:\s*([\d.]+)px;`)
+    certFieldRe  = regexp.MustCompile(`(?s)<div class="template-object" style="([^"]*)">\s*([^<]*?\{\{[^<]*?)\s*</div>`)
+    certQRRe     = regexp.MustCompile(`<img class="template-object" style="([^"]*)" src="\{\{\s*\.VerifyQR\s*\}\}"\s*/?>`)
+    certRGBRe    = regexp.MustCompile(`rgba?\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)`)
+)
+
+func parsePx(value string) float64 {
+    f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+    if err != nil {
+        return 0
+    }
+    return f
+}
+
+func parseCSSColor(value string) [3]int {
+    value = strings.TrimSpace(value)
+    if m := certRGBRe.FindStringSubmatch(value); m != nil {
+        r, _ := strconv.Atoi(m[1])
+        g, _ := strconv.Atoi(m[2])
+        b, _ := strconv.Atoi(m[3])
+        return [3]int{r, g, b}
+    }
+    if strings.HasPrefix(value, "#") && len(value) == 7 {
+        if n, err := strconv.ParseUint(value[1:], 16, 32); err == nil {
+            return [3]int{int(n >> 16 & 0xff), int(n >> 8 & 0xff), int(n & 0xff)}
+        }
+    }
+    return [3]int{0, 0, 0}
+}
+
+// NOTE: Read the layout back from the html made by static-hidden/editor.html,
+//       it only have absolute positioned div so a regex is enough. A box is
+//       any text with a placeholder in it, like
+//       `Held on {{ date .EventStart }}`. Return the canvas size and the
+//       field, a hand written template may have none. The qr is an
+//       `<img class="template-object" style="..." src="{{ .VerifyQR }}">`
+//       added by hand, the editor dont have it.
+func parseCertLayout(html string) (float64, float64, []certField) {
+    width, height := 800.0, 600.0
+    if m := certCanvasRe.FindStringSubmatch(html); m != nil {
+        if w := parsePx(m[1]); w > 0 {
+            width = w
+        }
+        if h := parsePx(m[2]); h > 0 {
+            height = h
+        }
+    }
+
+    fields := []certField{}
+    matches := certFieldRe.FindAllStringSubmatch(html, -1)
+    for _, m := range certQRRe.FindAllStringSubmatch(html, -1) {
+        matches = append(matches, []string{m[0], m[1], ""})
+    }
+    for _, m := range matches {
+        field := certField{Text: m[2], QR: m[2] == "", Size: 16}
+        for _, decl := range strings.Split(m[1], ";") {
+            key, value, ok := strings.Cut(decl, ":")
+            if !ok {
+                continue
+            }
+            value = strings.TrimSpace(value)
+            switch strings.TrimSpace(key) {
+            case "left":
+                field.X = parsePx(value)
+            case "top":
+                field.Y = parsePx(value)
+            case "width":
+                field.W = parsePx(value)
+            case "height":
+                field.H = parsePx(value)
+            case "color":
+                field.Color = parseCSSColor(value)
+            case "font-size":
+                if size := parsePx(value); size > 0 {
+                    field.Size = size
+                }
+            case "font-weight":
+                field.Bold = value == "bold" || parsePx(value) >= 600
+            case "font-style":
+                field.Italic = value == "italic"
+            }
+        }
+        if field.W > 0 && field.H > 0 {
+            fields = append(fields, field)
+        }
+    }
+    return width, height, fields
+}
+
+// Used when the html have no field we understand, name and event on the
+// middle and the id on the bottom.
+func defaultCertLayout(width float64, height float64) []certField {
+    return []certField{
+        {Text: "{{ .UserName }}", X: 0, Y: height*0.40, W: width, H: 50, Size: 32, Bold: true},
+        {Text: "{{ .EventName }}", X: 0, Y: height*0.55, W: width, H: 40, Size: 20},
+        {Text: "{{ .UniqueID }}", X: 0, Y: height - 50, W: width, H: 30, Size: 10},
+    }
+}
+
+// NOTE: Page is the canvas size in pt so the px of the editor map 1:1. The
+//       background is drawn like `background-size: cover`. Core font only,
+//       so the text go through the cp1252 translator.
+func renderCertificatePDF(backend *Backend, files *certTemplateFiles, data map[string]any, qr []byte, out io.Writer) error {
+    raw, err := os.ReadFile(files.HTMLPath)
+    if err != nil {
+        return err
+    }
+    width, height, fields := parseCertLayout(string(raw))
+    if len(fields) == 0 {
+        fields = defaultCertLayout(width, height)
+    }
+
+    pdf := fpdf.NewCustom(&fpdf.InitType{
+        UnitStr:        "pt",
+        Size:           fpdf.SizeType{Wd: width, Ht: height},
+        OrientationStr: "P",
+    })
+    pdf.SetMargins(0, 0, 0)
+    pdf.SetAutoPageBreak(false, 0)
+    pdf.AddPage()
+
+    if _, err := os.Stat(files.BGPath); err == nil {
+        info := pdf.RegisterImageOptions(files.BGPath, fpdf.ImageOptions{ImageType: "PNG"})
+        if pdf.Err() {
+            return pdf.Error()
+        }
+        scale := max(width/info.Width(), height/info.Height())
+        w, h := info.Width()*scale, info.Height()*scale
+        pdf.ClipRect(0, 0, width, height, false)
+        pdf.ImageOptions(files.BGPath, (width-w)/2, (height-h)/2, w, h, false, fpdf.ImageOptions{ImageType: "PNG", AllowNegativePosition: true}, 0, "")
+        pdf.ClipEnd()
+    }
+
+    funcs := certTemplateFuncs(backend.config.Locale)
+    partials, err := backend.engine.Partials()
+    if err != nil {
+        return err
+    }
+    tr := pdf.UnicodeTranslatorFromDescriptor("")
+    for _, field := range fields {
+        if field.QR {
+            // NOTE: Square, on the middle of the box like `object-fit: contain`.
+            side := min(field.W, field.H)
+            opt := fpdf.ImageOptions{ImageType: "PNG"}
+            pdf.RegisterImageOptionsReader("qr", opt, bytes.NewReader(qr))
+            pdf.ImageOptions("qr", field.X+(field.W-side)/2, field.Y+(field.H-side)/2, side, side, false, opt, 0, "")
+            continue
+        }
+
+        // NOTE: The box is text only, so text/template and unescape what the
+        //       editor escaped. A partial used on the box is parsed the same way.
+        tmpl := texttemplate.New(certTemplateName).Funcs(funcs)
+        for name, source := range partials {
+            if _, err := tmpl.New(name).Parse(source); err != nil {
+                return err
+            }
+        }
+        if _, err := tmpl.Parse(field.Text); err != nil {
+            return err
+        }
+        var sb strings.Builder
+        if err := tmpl.Execute(&sb, data); err != nil {
+            return err
+        }
+        text := tr(strings.TrimSpace(html.UnescapeString(sb.String())))
+
+        style := ""
+        if field.Bold {
+            style += "B"
+        }
+        if field.Italic {
+            style += "I"
+        }
+
+        // NOTE: Shrink instead of wrap, the box is fixed on the template.
+        size := field.Size
+        pdf.SetFont("Helvetica", style, size)
+        for size > 6 && pdf.GetStringWidth(text) > field.W-4 {
+            size--
+            pdf.SetFontSize(size)
+        }
+
+        pdf.SetTextColor(field.Color[0], field.Color[1], field.Color[2])
+        pdf.SetXY(field.X, field.Y)
+        pdf.CellFormat(field.W, field.H, text, "", 0, "CM", false, 0, "")
+    }
+
+    if pdf.Err() {
+        return pdf.Error()
+    }
+    return pdf.Output(out)
+}
+
+// NOTE: Cached on CacheDir/certificate, the name have the hash of everything
+//       that end up on the pdf (and the template and partials mtime) so a
+//       rename or a new template make a new file instead of serving the old
+//       one.
+func certificatePDF(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate, files *certTemplateFiles) (string, error) {
+    data := certificateData(backend, evPart, cert)
+
+    keys := make([]string, 0, len(data))
+    for key := range data {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    fingerprint := []string{files.HTMLPath, backend.engine.PartialsSignature()}
+    for _, key := range keys {
+        fingerprint = append(fingerprint, fmt.Sprint(data[key]))
+    }
+    for _, path := range []string{files.HTMLPath, files.BGPath} {
+        if info, err := os.Stat(path); err == nil {
+            fingerprint = append(fingerprint, info.ModTime().String(), strconv.FormatInt(info.Size(), 10))
+        }
+    }
+
+    dir := filepath.Join(backend.config.Upload.CacheDir, "certificate")
+    prefix := hashToken(evPart.EventPCode)[:32]
+    path := filepath.Join(dir, fmt.Sprintf("%s-%s.pdf", prefix, hashToken(strings.Join(fingerprint, "\n"))[:16]))
+    if _, err := os.Stat(path); err == nil {
+        return path, nil
+    }
+
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return "", err
+    }
+
+    // NOTE: Written to a temp file first so a half written pdf is never served.
+    tmp, err := os.CreateTemp(dir, prefix+"-*.tmp")
+    if err != nil {
+        return "", err
+    }
+    defer os.Remove(tmp.Name())
+
+    qr, err := certificateQR(backend, evPart, cert, 512)
+    if err != nil {
+        tmp.Close()
+        return "", err
+    }
+    err = renderCertificatePDF(backend, files, data, qr, tmp)
+    if cerr := tmp.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        return "", err
+    }
+
+    old, _ := filepath.Glob(filepath.Join(dir, prefix+"-*.pdf"))
+    for _, stale := range old {
+        os.Remove(stale)
+    }
+    if err := os.Rename(tmp.Name(), path); err != nil {
+        return "", err
+    }
+    return path, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "time"
+    "webrpl/table"
+)
+
+const certEmailBatchSize = 200
+
+// NOTE: Same claim idea as the reminder, eventp_cert_sent_at is set before the
+//       mail is queued so a second run (or the job and the committee at the
+//       same time) never mail the same participant twice. It is put back when
+//       queuing or the render fail so the next run try again. A revoked
+//       certificate keep the claim, reissueCertificate clear it so the new
+//       one still get sent. The pending rows are walked by id one batch at a
+//       time, so the one that keep failing dont hide the rest behind it.
+func sendCertificateEmails(backend *Backend, event *table.Event, attach bool) (int, error) {
+    if event.EventDEnd.After(time.Now()) {
+        return 0, errors.New("the event is not done yet")
+    }
+    files, err := resolveCertTemplate(backend, event.ID)
+    if err != nil {
+        return 0, err
+    }
+
+    count := 0
+    lastId := 0
+    for {
+        var parts []table.EventParticipant
+        res := backend.db.Preload("User").Preload("Event").
+            Where("event_id = ? AND eventp_come = ? AND eventp_cert_sent_at IS NULL", event.ID, true).
+            Where("id > ?", lastId).
+            Order("id").
+            Limit(certEmailBatchSize).
+            Find(&parts)
+        if res.Error != nil {
+            return count, res.Error
+        }
+        if len(parts) == 0 {
+            return count, nil
+        }
+        lastId = parts[len(parts)-1].ID
+
+        for i := range parts {
+            part := &parts[i]
+            now := time.Now()
+            claim := backend.db.Model(&table.EventParticipant{}).
+                Where("id = ? AND eventp_cert_sent_at IS NULL", part.ID).
+                Update("eventp_cert_sent_at", now)
+            if claim.Error != nil || claim.RowsAffected == 0 || part.User.ID == 0 {
+                continue
+            }
+
+            err := queueCertificateEmail(backend, part, files, attach)
+            if errors.Is(err, errCertRevoked) {
+                continue
+            }
+            if err != nil {
+                log.Printf("WARN: Failed to queue the certificate of participant %d, %v", part.ID, err)
+                backend.db.Model(&table.EventParticipant{}).
+                    Where("id = ?", part.ID).
+                    Update("eventp_cert_sent_at", nil)
+                continue
+            }
+            count++
+        }
+    }
+}
+
+func queueCertificateEmail(backend *Backend, part *table.EventParticipant, files *certTemplateFiles, attach bool) error {
+    cert, err := currentCertificate(backend, part)
+    if err != nil {
+        return err
+    }
+
+    link := publicURL(backend, "api/certificate/"+part.EventPCode)
+    body := fmt.Sprintf(
+        "Hi %s,\n\nThank you for attending \"%s\". Your certificate is ready.\nView : %s\nPDF : %s.pdf\nSerial : %s\nVerify : %s\n",
+        part.User.UserFullName,
+        part.Event.EventName,
+        link,
+        link,
+        cert.CertSerial,
+        certificateVerifyURL(backend, part, cert),
+    )
+
+    mail := Mail{
+        To:      []string{part.User.UserEmail},
+        Subject: fmt.Sprintf("Certificate : %s", part.Event.EventName),
+        Text:    body,
+    }
+    if attach {
+        path, err := certificatePDF(backend, part, cert, files)
+        if err != nil {
+            return err
+        }
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return err
+        }
+        mail.Attachments = []MailAttachment{{
+            Filename:    certificateExportName(part, cert, "pdf"),
+            ContentType: "application/pdf",
+            Data:        data,
+        }}
+    }
+
+    _, err = queueEmail(backend, &mail)
+    return err
+}
+
+// NOTE: Only the event that ended within certificate_email_within, and that
+//       have a template, the other are left to the committee.
+func jobCertificateEmail(backend *Backend) (string, error) {
+    now := time.Now()
+    var events []table.Event
+    res := backend.db.
+        Where("event_dend <= ? AND event_dend > ?", now, now.Add(-backend.config.Jobs.CertEmailWithin)).
+        Where("id IN (?)", backend.db.Model(&table.CertTemplate{}).Select("event_id")).
+        Find(&events)
+    if res.Error != nil {
+        return "", res.Error
+    }
+
+    count := 0
+    for i := range events {
+        sent, err := sendCertificateEmails(backend, &events[i], backend.config.Jobs.CertEmailAttach)
+        if err != nil {
+            log.Printf("WARN: Failed to mail the certificate of event %d, %v", events[i].ID, err)
+        }
+        count += sent
+    }
+    return fmt.Sprintf("queued %d certificate", count), nil
+}
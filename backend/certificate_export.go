@@ -0,0 +1,139 @@
+package main
+
+import (
+    "archive/zip"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "regexp"
+    "strings"
+    "webrpl/table"
+)
+
+var certExportNameRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// NOTE: The name only help the committee find the file, the serial (or the
+//       code when there is none) keep it unique.
+func certificateExportName(evPart *table.EventParticipant, cert *table.Certificate, ext string) string {
+    id := evPart.EventPCode
+    if cert != nil {
+        id = cert.CertSerial
+    }
+    name := strings.Trim(certExportNameRe.ReplaceAllString(evPart.User.UserFullName, "_"), "_")
+    if name == "" {
+        return fmt.Sprintf("%s.%s", id, ext)
+    }
+    return fmt.Sprintf("%s-%s.%s", id, name, ext)
+}
+
+// Write the certificate of every participant as a zip, with manifest.csv at
+// the end. A participant that fail is only marked on the manifest so one bad
+// row dont cost the whole archive, only a write error stop it. The answers of
+// the registration form come after the fixed columns.
+func writeCertificateZip(backend *Backend, out io.Writer, files *certTemplateFiles, parts []table.EventParticipant, form []table.EventFormField, format string) error {
+    zw := zip.NewWriter(out)
+    rows := [][]string{append([]string{"name", "email", "code", "serial", "status", "file"}, formAnswerHeader(form)...)}
+
+    for i := range parts {
+        evPart := &parts[i]
+        row := []string{evPart.User.UserFullName, evPart.User.UserEmail, evPart.EventPCode, "", "", ""}
+        answers := formAnswerRow(form, evPart.EventPAnswers)
+
+        cert, err := currentCertificate(backend, evPart)
+        if cert != nil {
+            row[3] = cert.CertSerial
+        }
+        if err != nil {
+            if errors.Is(err, errCertRevoked) {
+                row[4] = "revoked"
+            } else {
+                log.Printf("WARN: Failed to issue the certificate of participant %d, %v", evPart.ID, err)
+                row[4] = "error"
+            }
+            rows = append(rows, append(row, answers...))
+            continue
+        }
+
+        name := certificateExportName(evPart, cert, format)
+        err = writeCertificateEntry(backend, zw, name, files, evPart, cert, format)
+        if err != nil {
+            var werr *zipWriteError
+            if errors.As(err, &werr) {
+                return werr.err
+            }
+            log.Printf("WARN: Failed to render the certificate of participant %d, %v", evPart.ID, err)
+            row[4] = "error"
+            rows = append(rows, append(row, answers...))
+            continue
+        }
+        row[4] = "issued"
+        row[5] = name
+        rows = append(rows, append(row, answers...))
+    }
+
+    manifest, err := zw.Create("manifest.csv")
+    if err != nil {
+        return err
+    }
+    cw := csv.NewWriter(manifest)
+    for _, row := range rows {
+        for i := range row {
+            row[i] = csvSafe(row[i])
+        }
+    }
+    if err := cw.WriteAll(rows); err != nil {
+        return err
+    }
+    return zw.Close()
+}
+
+// NOTE: The name come from the user, a spreadsheet would run `=...` as a formula.
+func csvSafe(value string) string {
+    if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+        return "'" + value
+    }
+    return value
+}
+
+// Tell a broken output (client gone) apart from a certificate that failed.
+type zipWriteError struct {
+    err error
+}
+
+func (e *zipWriteError) Error() string { return e.err.Error() }
+
+// NOTE: Rendered before the entry is created, a zip entry can not be taken
+//       back once started.
+func writeCertificateEntry(backend *Backend, zw *zip.Writer, name string, files *certTemplateFiles, evPart *table.EventParticipant, cert *table.Certificate, format string) error {
+    var content io.Reader
+    if format == "pdf" {
+        path, err := certificatePDF(backend, evPart, cert, files)
+        if err != nil {
+            return err
+        }
+        f, err := os.Open(path)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        content = f
+    } else {
+        var sb strings.Builder
+        if err := backend.engine.Render(&sb, files.Name, certificateData(backend, evPart, cert)); err != nil {
+            return err
+        }
+        content = strings.NewReader(sb.String())
+    }
+
+    w, err := zw.Create(name)
+    if err != nil {
+        return &zipWriteError{err}
+    }
+    if _, err := io.Copy(w, content); err != nil {
+        return &zipWriteError{err}
+    }
+    return nil
+}
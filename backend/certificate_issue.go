@@ -0,0 +1,195 @@
+package main
+
+import (
+    "crypto/rand"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+    "webrpl/table"
+)
+
+const certIssueBatchSize = 500
+
+var (
+    errCertRevoked   = errors.New("certificate has been revoked")
+    errCertNotIssued = errors.New("certificate can not be issued for this participant")
+)
+
+// NOTE: Only for human to read and quote, eg. `2026-K3J9QW7ZP2XA`. What
+//       prove the certificate is the verify code, not this.
+func newCertificateSerial(issuedAt time.Time) string {
+    return fmt.Sprintf("%d-%s", issuedAt.Year(), rand.Text()[:12])
+}
+
+// The template the certificate is issued with and its active version, so a
+// later edit of the template can be told apart. Nil when there is none.
+func certTemplateVersion(backend *Backend, eventId int) (*int, *int) {
+    var cerTemp table.CertTemplate
+    res := backend.db.Where("event_id = ?", eventId).Limit(1).Find(&cerTemp)
+    if res.Error != nil || res.RowsAffected == 0 {
+        return nil, nil
+    }
+    return &cerTemp.ID, cerTemp.CertActiveVersion
+}
+
+// Latest certificate of the participant, nil when nothing was issued yet.
+func latestCertificate(backend *Backend, participantId int) (*table.Certificate, error) {
+    var cert table.Certificate
+    res := backend.db.Where("cert_participant_id = ?", participantId).
+        Order("cert_revision DESC").
+        Limit(1).Find(&cert)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return nil, nil
+    }
+    return &cert, nil
+}
+
+// NOTE: When another request already issued that revision the insert fail on
+//       the unique index, then their row is returned instead. signedAt is the
+//       one of the previous revision, zero for the first one.
+func issueCertificate(backend *Backend, evPart *table.EventParticipant, revision int, signedAt time.Time) (*table.Certificate, error) {
+    templateId, versionId := certTemplateVersion(backend, evPart.EventId)
+    now := time.Now()
+    if signedAt.IsZero() {
+        signedAt = now
+    }
+    cert := table.Certificate{
+        CertParticipantId:     evPart.ID,
+        CertRevision:          revision,
+        CertTemplateId:        templateId,
+        CertTemplateVersionId: versionId,
+        CertSerial:            newCertificateSerial(now),
+        CertIssuedAt:          now,
+        CertSignedAt:          signedAt,
+    }
+    if err := backend.db.Create(&cert).Error; err != nil {
+        var existing table.Certificate
+        res := backend.db.Where("cert_participant_id = ? AND cert_revision = ?", evPart.ID, revision).
+            Limit(1).Find(&existing)
+        if res.Error == nil && res.RowsAffected > 0 {
+            return &existing, nil
+        }
+        return nil, err
+    }
+    return &cert, nil
+}
+
+// NOTE: The certificate shown to the participant. Nothing issued yet mean the
+//       attendance came before the end and the job did not run yet, so it is
+//       issued here. A revoked one only come back with certificate-reissue.
+func currentCertificate(backend *Backend, evPart *table.EventParticipant) (*table.Certificate, error) {
+    cert, err := latestCertificate(backend, evPart.ID)
+    if err != nil {
+        return nil, err
+    }
+    if cert == nil {
+        return issueCertificate(backend, evPart, 1, time.Time{})
+    }
+    if cert.CertRevokedAt != nil {
+        return cert, errCertRevoked
+    }
+    return cert, nil
+}
+
+// Issue the missing certificate of the participant that came to an ended
+// event, eventId 0 mean every event.
+func issueCertificates(backend *Backend, eventId int) (int, error) {
+    ended := backend.db.Model(&table.Event{}).
+        Select("id").
+        Where("event_dend <= ?", time.Now())
+    if eventId != 0 {
+        ended = ended.Where("id = ?", eventId)
+    }
+    issued := backend.db.Model(&table.Certificate{}).Select("cert_participant_id")
+
+    var parts []table.EventParticipant
+    res := backend.db.
+        Where("eventp_come = ? AND event_id IN (?) AND id NOT IN (?)", true, ended, issued).
+        Limit(certIssueBatchSize).
+        Find(&parts)
+    if res.Error != nil {
+        return 0, res.Error
+    }
+
+    count := 0
+    for _, part := range parts {
+        if _, err := issueCertificate(backend, &part, 1, time.Time{}); err != nil {
+            log.Printf("WARN: Failed to issue the certificate of participant %d, %v", part.ID, err)
+            continue
+        }
+        count++
+    }
+    return count, nil
+}
+
+// NOTE: Called after an attendance change, the event may not be over yet and
+//       then there is nothing to do. A failure here is left to the job.
+func issueCertificatesOf(backend *Backend, eventId int) {
+    if _, err := issueCertificates(backend, eventId); err != nil {
+        log.Printf("WARN: Failed to issue the certificate of event %d, %v", eventId, err)
+    }
+}
+
+func revokeCertificate(backend *Backend, cert *table.Certificate, reason string) error {
+    res := backend.db.Model(&table.Certificate{}).
+        Where("id = ? AND cert_revoked_at IS NULL", cert.ID).
+        Updates(map[string]any{
+            "cert_revoked_at":    time.Now(),
+            "cert_revoke_reason": reason,
+        })
+    if res.Error != nil {
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        return errCertRevoked
+    }
+    return nil
+}
+
+// Revoke the current certificate of the participant (when still active) and
+// issue the next revision with a new serial. The certificate mail is sent
+// again for the new one.
+func reissueCertificate(backend *Backend, participantId int) (*table.Certificate, error) {
+    var evPart table.EventParticipant
+    res := backend.db.Preload("Event").
+        Where("id = ? AND eventp_come = ?", participantId, true).
+        Limit(1).Find(&evPart)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+    if res.RowsAffected == 0 || evPart.Event.EventDEnd.After(time.Now()) {
+        return nil, errCertNotIssued
+    }
+
+    latest, err := latestCertificate(backend, evPart.ID)
+    if err != nil {
+        return nil, err
+    }
+    revision := 1
+    var signedAt time.Time
+    if latest != nil {
+        if latest.CertRevokedAt == nil {
+            if err := revokeCertificate(backend, latest, "reissued"); err != nil && !errors.Is(err, errCertRevoked) {
+                return nil, err
+            }
+        }
+        revision = latest.CertRevision + 1
+        signedAt = latest.CertSignedAt
+    }
+    cert, err := issueCertificate(backend, &evPart, revision, signedAt)
+    if err != nil {
+        return nil, err
+    }
+
+    res = backend.db.Model(&table.EventParticipant{}).
+        Where("id = ?", evPart.ID).
+        Update("eventp_cert_sent_at", nil)
+    if res.Error != nil {
+        log.Printf("WARN: Failed to reset the certificate mail of participant %d, %v", evPart.ID, res.Error)
+    }
+    return cert, nil
+}
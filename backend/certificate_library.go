@@ -0,0 +1,120 @@
+package main
+
+import (
+    "encoding/base64"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "webrpl/table"
+)
+
+// NOTE: Same marker as the old cert-upload-template, the library html point
+//       to its background with it since the hidden dir have no public url.
+const certLibraryBGMarker = "@@"
+
+func certLibraryFiles(backend *Backend, lib *table.CertLibrary) *certTemplateFiles {
+    files := &certTemplateFiles{
+        HTMLPath: filepath.Join(backend.config.Upload.HiddenDir, lib.CertLPath),
+        Name:     strings.TrimSuffix(lib.CertLPath, ".html"),
+    }
+    files.BGPath = filepath.Join(filepath.Dir(files.HTMLPath), "bg.png")
+    return files
+}
+
+// The row is made first for the id (the folder name), it is removed again
+// when the file can not be written.
+func createCertLibrary(backend *Backend, lib *table.CertLibrary, html []byte, bg []byte) error {
+    if err := backend.db.Create(lib).Error; err != nil {
+        return err
+    }
+
+    lib.CertLPath = fmt.Sprintf("library/%d/index.html", lib.ID)
+    files := certLibraryFiles(backend, lib)
+    err := os.MkdirAll(filepath.Dir(files.HTMLPath), 0755)
+    if err == nil && bg != nil {
+        err = os.WriteFile(files.BGPath, bg, 0644)
+    }
+    if err == nil {
+        err = os.WriteFile(files.HTMLPath, html, 0644)
+    }
+    if err == nil {
+        err = backend.db.Model(lib).Update("certl_path", lib.CertLPath).Error
+    }
+    if err != nil {
+        os.RemoveAll(filepath.Dir(files.HTMLPath))
+        backend.db.Unscoped().Delete(&table.CertLibrary{}, lib.ID)
+        return err
+    }
+    return nil
+}
+
+// NOTE: Hard delete, an event that used it already have its own copy and the
+//       name can be taken again.
+func deleteCertLibrary(backend *Backend, lib *table.CertLibrary) error {
+    if err := backend.db.Unscoped().Delete(&table.CertLibrary{}, lib.ID).Error; err != nil {
+        return err
+    }
+    if lib.CertLPath == "" {
+        return nil
+    }
+    return os.RemoveAll(filepath.Dir(certLibraryFiles(backend, lib).HTMLPath))
+}
+
+// Copy a library template to the event as a new version (and make it the
+// active one). The `@@` become the draft url, saveCertTemplateVersion point
+// it to the copy of the background.
+func useCertLibrary(backend *Backend, lib *table.CertLibrary, certTemp *table.CertTemplate, authorId *int) (*table.CertTemplateVersion, error) {
+    files := certLibraryFiles(backend, lib)
+    html, err := os.ReadFile(files.HTMLPath)
+    if err != nil {
+        return nil, err
+    }
+
+    html = []byte(strings.ReplaceAll(string(html), certLibraryBGMarker, publicURL(backend, certTemplateDraftURL(certTemp.EventId))))
+    return saveCertTemplateVersion(backend, certTemp, html, files.BGPath, authorId)
+}
+
+// NOTE: Not cached by the engine, the `@@` is swapped for the background
+//       inlined since the hidden dir can not be fetched by the browser.
+func renderCertLibraryHTML(backend *Backend, files *certTemplateFiles, out io.Writer, data map[string]any) error {
+    html, err := os.ReadFile(files.HTMLPath)
+    if err != nil {
+        return err
+    }
+
+    bgURL := ""
+    if bg, err := os.ReadFile(files.BGPath); err == nil {
+        bgURL = "data:image/png;base64," + base64.StdEncoding.EncodeToString(bg)
+    }
+
+    tmpl, err := backend.engine.Parse(certTemplateName, strings.ReplaceAll(string(html), certLibraryBGMarker, bgURL))
+    if err != nil {
+        return err
+    }
+    return tmpl.Execute(out, data)
+}
+
+// The CertTemplate of the event, made when there is none yet (the path is
+// set by the first version). gorm.ErrRecordNotFound when the event is gone.
+func certTemplateOfEvent(backend *Backend, eventId int) (*table.CertTemplate, error) {
+    var certTemp table.CertTemplate
+    res := backend.db.Where("event_id = ?", eventId).Limit(1).Find(&certTemp)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+    if res.RowsAffected > 0 {
+        return &certTemp, nil
+    }
+
+    var event table.Event
+    if err := backend.db.Where("id = ?", eventId).First(&event).Error; err != nil {
+        return nil, err
+    }
+    certTemp = table.CertTemplate{EventId: eventId}
+    if err := backend.db.Create(&certTemp).Error; err != nil {
+        return nil, err
+    }
+    return &certTemp, nil
+}
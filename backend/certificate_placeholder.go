@@ -0,0 +1,308 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "html/template"
+    "io"
+    "regexp"
+    "sort"
+    "strings"
+    "text/template/parse"
+    "time"
+)
+
+// NOTE: Every `{{ .Key }}` a certificate template can use, the upload is
+//       refused on anything else. certificateData and certificateSampleData
+//       must give all of them.
+var certPlaceholders = []struct {
+    Name string `json:"name"`
+    Desc string `json:"desc"`
+}{
+    {"UserName", "Full name of the participant."},
+    {"UserInstance", "Instance (school, company, ...) of the participant."},
+    {"UniqueID", "Participant code."},
+    {"EventName", "Name of the event."},
+    {"EventSpeaker", "Speaker of the event."},
+    {"EventStart", "Start of the event, a date."},
+    {"EventEnd", "End of the event, a date."},
+    {"Serial", "Serial number of the certificate."},
+    {"IssuedAt", "Issue date of the certificate, a date."},
+    {"VerifyURL", "Public page to verify the certificate."},
+    {"VerifyQR", "QR code image of VerifyURL, for `<img src=\"{{ .VerifyQR }}\">`."},
+}
+
+var certFunctions = []struct {
+    Name string `json:"name"`
+    Desc string `json:"desc"`
+}{
+    {"date", "`{{ date .EventStart }}` or `{{ date .EventStart \"id\" }}`, like 2 January 2006."},
+    {"datetime", "`{{ datetime .EventStart }}`, like 2 January 2006 15:04."},
+    {"dateformat", "`{{ dateformat .EventStart \"Monday, 2 Jan 2006\" \"id\" }}`, Go layout with the name on the locale."},
+    {"upper", "`{{ upper .UserName }}`."},
+    {"lower", "`{{ lower .UserName }}`."},
+}
+
+// NOTE: The builtin of text/template that are harmless on a certificate, the
+//       other (call, index, slice, ...) are not defined for the upload.
+var certBuiltins = []string{"and", "or", "not", "eq", "ne", "lt", "le", "gt", "ge", "len", "print", "printf", "println"}
+
+var certLocales = map[string]*strings.Replacer{
+    "en": newCertLocale(
+        [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+        [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+        [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+    ),
+    "id": newCertLocale(
+        [12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+        [7]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"},
+        [12]string{"Jan", "Feb", "Mar", "Apr", "Mei", "Jun", "Jul", "Agu", "Sep", "Okt", "Nov", "Des"},
+        [7]string{"Min", "Sen", "Sel", "Rab", "Kam", "Jum", "Sab"},
+    ),
+}
+
+// NOTE: time.Format only speak english, so the output is translated after.
+//       One pass of strings.Replacer, the full name are listed first so
+//       `January` never become `Janiary`.
+func newCertLocale(months [12]string, days [7]string, shortMonths [12]string, shortDays [7]string) *strings.Replacer {
+    pairs := []string{}
+    for i := range months {
+        pairs = append(pairs, time.Month(i+1).String(), months[i])
+    }
+    for i := range days {
+        pairs = append(pairs, time.Weekday(i).String(), days[i])
+    }
+    for i := range shortMonths {
+        pairs = append(pairs, time.Month(i+1).String()[:3], shortMonths[i])
+    }
+    for i := range shortDays {
+        pairs = append(pairs, time.Weekday(i).String()[:3], shortDays[i])
+    }
+    return strings.NewReplacer(pairs...)
+}
+
+const (
+    certDateLayout     = "02 January 2006"
+    certDateTimeLayout = "02 January 2006 15:04"
+)
+
+func formatCertDate(t time.Time, layout string, locale string) string {
+    replacer, ok := certLocales[locale]
+    if !ok {
+        replacer = certLocales["en"]
+    }
+    return replacer.Replace(t.Format(layout))
+}
+
+// A date given to the template, `{{ .IssuedAt }}` alone still print the date
+// the way it used to, the helper can format it some other way.
+type certDate struct {
+    Time   time.Time
+    Locale string
+}
+
+func (d certDate) String() string {
+    return formatCertDate(d.Time, certDateLayout, d.Locale)
+}
+
+// NOTE: Registered on the engine (and used by the pdf), locale is the default
+//       when the template dont give one.
+func certTemplateFuncs(locale string) map[string]any {
+    toDate := func(name string, value any, locales []string) (certDate, error) {
+        var d certDate
+        switch v := value.(type) {
+        case certDate:
+            d = v
+        case time.Time:
+            d = certDate{Time: v, Locale: locale}
+        default:
+            return d, fmt.Errorf("%s want a date, got %T", name, value)
+        }
+        if len(locales) > 0 {
+            if _, ok := certLocales[locales[0]]; !ok {
+                return d, fmt.Errorf("%s unknown locale %q", name, locales[0])
+            }
+            d.Locale = locales[0]
+        }
+        return d, nil
+    }
+
+    return map[string]any{
+        "date": func(value any, locales ...string) (string, error) {
+            d, err := toDate("date", value, locales)
+            if err != nil {
+                return "", err
+            }
+            return formatCertDate(d.Time, certDateLayout, d.Locale), nil
+        },
+        "datetime": func(value any, locales ...string) (string, error) {
+            d, err := toDate("datetime", value, locales)
+            if err != nil {
+                return "", err
+            }
+            return formatCertDate(d.Time, certDateTimeLayout, d.Locale), nil
+        },
+        "dateformat": func(value any, layout string, locales ...string) (string, error) {
+            d, err := toDate("dateformat", value, locales)
+            if err != nil {
+                return "", err
+            }
+            return formatCertDate(d.Time, layout, d.Locale), nil
+        },
+        "upper": strings.ToUpper,
+        "lower": strings.ToLower,
+    }
+}
+
+// One problem of an uploaded template, line start at 1 (0 when unknown).
+type certTemplateError struct {
+    Line    int    `json:"line"`
+    Message string `json:"message"`
+}
+
+func (e certTemplateError) Error() string {
+    return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+const certTemplateName = "certificate"
+
+var certTemplateErrRe = regexp.MustCompile(`^(?:html/)?template: ?` + certTemplateName + `:(\d+)(?::\d+)?: (.*)$`)
+
+func newCertTemplateError(err error) certTemplateError {
+    msg := err.Error()
+    if m := certTemplateErrRe.FindStringSubmatch(msg); m != nil {
+        var line int
+        fmt.Sscan(m[1], &line)
+        return certTemplateError{Line: line, Message: m[2]}
+    }
+    return certTemplateError{Message: msg}
+}
+
+// Check an uploaded template before it is saved, so the committee get the
+// line of the mistake instead of a broken certificate. Parsed with only the
+// allowed function, then every field is checked (a variable can only be the
+// `$` of the data, declaring one is refused), then it is rendered once
+// (with the partials of the engine) and the sample data to catch what only
+// html/template see (like a placeholder inside a <script>). Nil mean ok.
+func validateCertTemplate(backend *Backend, html string) []certTemplateError {
+    funcs := certTemplateFuncs(backend.config.Locale)
+    partials, err := backend.engine.Partials()
+    if err != nil {
+        return []certTemplateError{{Message: err.Error()}}
+    }
+
+    // NOTE: parse only look if the name is there (and not nil).
+    known := map[string]any{}
+    for name := range funcs {
+        known[name] = true
+    }
+    for _, name := range certBuiltins {
+        known[name] = true
+    }
+
+    trees, err := parse.Parse(certTemplateName, html, "", "", known)
+    if err != nil {
+        return []certTemplateError{newCertTemplateError(err)}
+    }
+    for name, tree := range trees {
+        if name != certTemplateName {
+            line := strings.Count(html[:int(tree.Root.Position())], "\n") + 1
+            return []certTemplateError{{Line: line, Message: "define and block are not allowed"}}
+        }
+    }
+
+    allowed := map[string]bool{}
+    for _, p := range certPlaceholders {
+        allowed[p.Name] = true
+    }
+
+    errs := []certTemplateError{}
+    report := func(node parse.Node, format string, args ...any) {
+        line := strings.Count(html[:int(node.Position())], "\n") + 1
+        errs = append(errs, certTemplateError{Line: line, Message: fmt.Sprintf(format, args...)})
+    }
+    checkField := func(node parse.Node, ident []string) {
+        if len(ident) != 1 {
+            report(node, "only a plain placeholder is allowed, got .%s", strings.Join(ident, "."))
+        } else if !allowed[ident[0]] {
+            report(node, "unknown placeholder .%s", ident[0])
+        }
+    }
+
+    var walk func(node parse.Node)
+    walk = func(node parse.Node) {
+        switch n := node.(type) {
+        case *parse.ListNode:
+            if n == nil {
+                return
+            }
+            for _, child := range n.Nodes {
+                walk(child)
+            }
+        case *parse.ActionNode:
+            walk(n.Pipe)
+        case *parse.IfNode:
+            walk(n.Pipe)
+            walk(n.List)
+            walk(n.ElseList)
+        case *parse.RangeNode:
+            walk(n.Pipe)
+            walk(n.List)
+            walk(n.ElseList)
+        case *parse.WithNode:
+            walk(n.Pipe)
+            walk(n.List)
+            walk(n.ElseList)
+        case *parse.PipeNode:
+            if n == nil {
+                return
+            }
+            // NOTE: `{{ with $x := . }}{{ $x.Anything }}` would get past the
+            //       field check, there is nothing a placeholder need them for.
+            if len(n.Decl) > 0 {
+                report(n, "variables are not allowed, use the placeholder directly")
+            }
+            for _, cmd := range n.Cmds {
+                walk(cmd)
+            }
+        case *parse.CommandNode:
+            for _, arg := range n.Args {
+                walk(arg)
+            }
+        case *parse.FieldNode:
+            checkField(n, n.Ident)
+        case *parse.VariableNode:
+            if len(n.Ident) > 1 && n.Ident[0] != "$" {
+                report(n, "only a plain placeholder is allowed, got %s", strings.Join(n.Ident, "."))
+            } else if len(n.Ident) > 1 {
+                checkField(n, n.Ident[1:])
+            }
+        case *parse.ChainNode:
+            report(n, "only a plain placeholder is allowed")
+        case *parse.TemplateNode:
+            if _, ok := partials[n.Name]; !ok {
+                report(n, "unknown partial %q", n.Name)
+            }
+            walk(n.Pipe)
+        }
+    }
+    walk(trees[certTemplateName].Root)
+    if len(errs) > 0 {
+        sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+        return errs
+    }
+
+    tmpl, err := backend.engine.Parse(certTemplateName, html)
+    if err == nil {
+        err = tmpl.Execute(io.Discard, certificateSampleData(backend, nil, nil))
+    }
+    if err != nil {
+        var terr *template.Error
+        if errors.As(err, &terr) && terr.Line > 0 {
+            return []certTemplateError{{Line: terr.Line, Message: terr.Description}}
+        }
+        return []certTemplateError{newCertTemplateError(err)}
+    }
+    return nil
+}
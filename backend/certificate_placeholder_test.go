@@ -0,0 +1,124 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "testing"
+)
+
+// A backend with only what validateCertTemplate use, and a `footer` partial.
+func newCertTemplateTestBackend(t *testing.T) *Backend {
+    t.Helper()
+
+    dir := t.TempDir()
+    partials := filepath.Join(dir, "partials")
+    if err := os.MkdirAll(partials, 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(partials, "footer.html"), []byte("<small>{{ .Serial }}</small>"), 0644); err != nil {
+        t.Fatal(err)
+    }
+
+    config := &Config{Locale: "en", PublicBaseURL: "http://localhost:3000"}
+    engine := NewDynamicEngine([]string{dir}, ".html", partials)
+    for name, fn := range certTemplateFuncs(config.Locale) {
+        engine.AddFunc(name, fn)
+    }
+    return &Backend{engine: engine, config: config}
+}
+
+func TestValidateCertTemplateRejects(t *testing.T) {
+    backend := newCertTemplateTestBackend(t)
+
+    tests := []struct {
+        name string
+        html string
+        line int
+        want string
+    }{
+        {"unknown field", "<p>\n{{ .UserPassword }}</p>", 2, "unknown placeholder .UserPassword"},
+        {"unknown field from root", "{{ $.UserPassword }}", 1, "unknown placeholder .UserPassword"},
+        {"field chain", "{{ .Participant.User }}", 1, "only a plain placeholder"},
+        {"field chain from root", "{{ $.UserName.Len }}", 1, "only a plain placeholder"},
+        {"chain on a pipe", "{{ (.UserName).Len }}", 1, "only a plain placeholder"},
+        {"declare in with", "{{ with $x := . }}{{ $x.Anything }}{{ end }}", 1, "variables are not allowed"},
+        {"declare", "\n\n{{ $x := .UserName }}{{ $x }}", 3, "variables are not allowed"},
+        {"declare in range", "{{ range $i, $v := .UserName }}{{ end }}", 1, "variables are not allowed"},
+        {"define", "<p></p>\n{{ define \"x\" }}hi{{ end }}", 2, "define and block are not allowed"},
+        {"block", "{{ block \"x\" . }}hi{{ end }}", 1, "define and block are not allowed"},
+        {"unknown partial", "<p>\n</p>\n{{ template \"header\" . }}", 3, "unknown partial \"header\""},
+        {"call", "\n{{ call .UserName }}", 2, "\"call\" not defined"},
+        {"index", "{{ index .UserName 0 }}", 1, "\"index\" not defined"},
+        {"slice", "{{ slice .UserName 1 }}", 1, "\"slice\" not defined"},
+        {"unknown function", "{{ exec .UserName }}", 1, "\"exec\" not defined"},
+        {"unclosed action", "<p>\n{{ .UserName </p>", 2, ""},
+        {"wrong argument", "{{ date .UserName }}", 1, "date want a date"},
+    }
+    for _, tt := range tests {
+        errs := validateCertTemplate(backend, tt.html)
+        if len(errs) == 0 {
+            t.Errorf("%s: %q was accepted", tt.name, tt.html)
+            continue
+        }
+        if errs[0].Line != tt.line {
+            t.Errorf("%s: line = %d, want %d (%v)", tt.name, errs[0].Line, tt.line, errs)
+        }
+        if !strings.Contains(errs[0].Message, tt.want) {
+            t.Errorf("%s: message = %q, want it to contain %q", tt.name, errs[0].Message, tt.want)
+        }
+    }
+}
+
+// Every mistake is reported, sorted by line.
+func TestValidateCertTemplateLines(t *testing.T) {
+    backend := newCertTemplateTestBackend(t)
+
+    html := "<html>\n<p>{{ .UserName }}</p>\n<p>{{ .Nope }}</p>\n\n<p>{{ .Event.EventName }}</p>\n{{ template \"nope\" . }}\n</html>"
+    errs := validateCertTemplate(backend, html)
+    want := []int{3, 5, 6}
+    if len(errs) != len(want) {
+        t.Fatalf("got %d error, want %d: %v", len(errs), len(want), errs)
+    }
+    for i, line := range want {
+        if errs[i].Line != line {
+            t.Errorf("error %d on line %d, want %d: %v", i, errs[i].Line, line, errs[i])
+        }
+    }
+}
+
+func TestValidateCertTemplateAccepts(t *testing.T) {
+    backend := newCertTemplateTestBackend(t)
+
+    tests := []string{
+        "<p>nothing to fill</p>",
+        "{{ template \"footer\" . }}",
+        "{{ with .EventName }}{{ . }}{{ end }}",
+        "{{ if eq .UserInstance \"\" }}-{{ else }}{{ .UserInstance }}{{ end }}",
+        "{{ printf \"%s (%s)\" .UserName .UserInstance }}",
+        "{{ if and .UserName (not .UserInstance) }}{{ len .UserName }}{{ end }}",
+        "<img src=\"{{ .VerifyQR }}\"><a href=\"{{ .VerifyURL }}\">{{ .Serial }}</a>",
+    }
+    for _, p := range certPlaceholders {
+        tests = append(tests, "{{ ."+p.Name+" }}", "{{ $."+p.Name+" }}")
+    }
+
+    // The example on the description of every function, the same the editor show.
+    example := regexp.MustCompile("`(\\{\\{[^`]*\\}\\})`")
+    for _, f := range certFunctions {
+        found := example.FindAllStringSubmatch(f.Desc, -1)
+        if len(found) == 0 {
+            t.Errorf("function %s have no example on its description", f.Name)
+        }
+        for _, m := range found {
+            tests = append(tests, m[1])
+        }
+    }
+
+    for _, html := range tests {
+        if errs := validateCertTemplate(backend, html); errs != nil {
+            t.Errorf("%q was refused: %v", html, errs)
+        }
+    }
+}
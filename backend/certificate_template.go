@@ -0,0 +1,200 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "html/template"
+    "log"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+    "webrpl/table"
+
+    "github.com/skip2/go-qrcode"
+)
+
+var errCertVersionNotFound = errors.New("certificate template version not found")
+
+// NOTE: The editor put the background here while editing, it only become part
+//       of a template when the html is saved, so an upload alone never change
+//       what the participant see. A save consume it, else it would win over
+//       the active version on every save after (a rollback too).
+func certTemplateDraftBG(backend *Backend, eventId int) string {
+    return filepath.Join(backend.config.Upload.StaticDir, strconv.Itoa(eventId), "draft", "bg.png")
+}
+
+func certTemplateDraftURL(eventId int) string {
+    return fmt.Sprintf("static/%d/draft/bg.png", eventId)
+}
+
+// NOTE: The number is claimed by inserting the row first, the unique index
+//       send a concurrent save to the next number instead of the same folder.
+func claimCertTemplateVersion(backend *Backend, cerTemp *table.CertTemplate, authorId *int) (*table.CertTemplateVersion, error) {
+    var err error
+    for range 3 {
+        var last int
+        res := backend.db.Model(&table.CertTemplateVersion{}).
+            Unscoped().
+            Where("certv_template_id = ?", cerTemp.ID).
+            Select("COALESCE(MAX(certv_number), 0)").
+            Scan(&last)
+        if res.Error != nil {
+            return nil, res.Error
+        }
+
+        version := table.CertTemplateVersion{
+            CertVTemplateId: cerTemp.ID,
+            CertVNumber:     last + 1,
+            CertVPath:       fmt.Sprintf("%d/v%d/index.html", cerTemp.EventId, last+1),
+            CertVAuthorId:   authorId,
+        }
+        if err = backend.db.Create(&version).Error; err == nil {
+            return &version, nil
+        }
+    }
+    return nil, err
+}
+
+// NOTE: The background an editor save go with, the draft when one was
+//       uploaded, else the one of the active version so the pdf still have it.
+func certTemplateBGSource(backend *Backend, cerTemp *table.CertTemplate) string {
+    draft := certTemplateDraftBG(backend, cerTemp.EventId)
+    if _, err := os.Stat(draft); err == nil {
+        return draft
+    }
+    if cerTemp.CertTemplate == "" {
+        return ""
+    }
+    return newCertTemplateFiles(backend, *cerTemp, cerTemp.CertTemplate).BGPath
+}
+
+// Save the html (and a copy of bgPath, if any) as a new version and make it
+// the active one. The draft url on the html is pointed to the copy and the
+// draft background is removed.
+func saveCertTemplateVersion(backend *Backend, cerTemp *table.CertTemplate, html []byte, bgPath string, authorId *int) (*table.CertTemplateVersion, error) {
+    version, err := claimCertTemplateVersion(backend, cerTemp, authorId)
+    if err != nil {
+        return nil, err
+    }
+
+    files := newCertTemplateFiles(backend, *cerTemp, version.CertVPath)
+    hash, err := writeCertTemplateFiles(cerTemp.EventId, files, bgPath, version.CertVNumber, html)
+    if err != nil {
+        os.RemoveAll(filepath.Dir(files.HTMLPath))
+        backend.db.Unscoped().Delete(&table.CertTemplateVersion{}, version.ID)
+        return nil, err
+    }
+
+    version.CertVHash = hash
+    if err := backend.db.Model(version).Update("certv_hash", hash).Error; err != nil {
+        return nil, err
+    }
+    if err := activateCertTemplateVersion(backend, cerTemp, version); err != nil {
+        return nil, err
+    }
+
+    draft := certTemplateDraftBG(backend, cerTemp.EventId)
+    if err := os.Remove(draft); err != nil && !errors.Is(err, os.ErrNotExist) {
+        log.Printf("WARN: Failed to remove the draft background %s, %v", draft, err)
+    }
+    return version, nil
+}
+
+func writeCertTemplateFiles(eventId int, files *certTemplateFiles, bgPath string, number int, html []byte) (string, error) {
+    if err := os.MkdirAll(filepath.Dir(files.HTMLPath), 0755); err != nil {
+        return "", err
+    }
+
+    var bg []byte
+    if bgPath != "" {
+        var err error
+        bg, err = os.ReadFile(bgPath)
+        if err == nil {
+            err = os.WriteFile(files.BGPath, bg, 0644)
+        }
+        if err != nil && !errors.Is(err, os.ErrNotExist) {
+            return "", err
+        }
+    }
+
+    html = []byte(strings.ReplaceAll(string(html), certTemplateDraftURL(eventId), fmt.Sprintf("static/%d/v%d/bg.png", eventId, number)))
+    if err := os.WriteFile(files.HTMLPath, html, 0644); err != nil {
+        return "", err
+    }
+    // Shown on the version list, two save of the same file get the same one.
+    h := sha256.New()
+    h.Write(html)
+    h.Write(bg)
+    return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+func activateCertTemplateVersion(backend *Backend, cerTemp *table.CertTemplate, version *table.CertTemplateVersion) error {
+    res := backend.db.Model(&table.CertTemplate{}).
+        Where("id = ?", cerTemp.ID).
+        Updates(map[string]any{
+            "cert_template":       version.CertVPath,
+            "cert_active_version": version.ID,
+        })
+    if res.Error != nil {
+        return res.Error
+    }
+    cerTemp.CertTemplate = version.CertVPath
+    cerTemp.CertActiveVersion = &version.ID
+    return nil
+}
+
+// number 0 mean the active one.
+func findCertTemplateVersion(backend *Backend, cerTemp *table.CertTemplate, number int) (*table.CertTemplateVersion, error) {
+    query := backend.db.Where("certv_template_id = ?", cerTemp.ID)
+    if number == 0 {
+        if cerTemp.CertActiveVersion == nil {
+            return nil, errCertVersionNotFound
+        }
+        query = query.Where("id = ?", *cerTemp.CertActiveVersion)
+    } else {
+        query = query.Where("certv_number = ?", number)
+    }
+
+    var version table.CertTemplateVersion
+    res := query.Limit(1).Find(&version)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return nil, errCertVersionNotFound
+    }
+    return &version, nil
+}
+
+// NOTE: What a preview (and the upload check) is rendered with, the qr point
+//       to a verify page that dont exist, it is only there to show where it
+//       goes. Inlined, template.URL so html/template dont turn it into #ZgotmplZ.
+func certificateSampleData(backend *Backend, event *table.Event, qr []byte) map[string]any {
+    now := time.Now()
+    locale := backend.config.Locale
+    if event == nil {
+        event = &table.Event{EventName: "Sample Event", EventSpeaker: "John Doe", EventDStart: now.Add(-2 * time.Hour), EventDEnd: now}
+    }
+    return map[string]any{
+        "UniqueID":     "SAMPLE-PARTICIPANT-CODE",
+        "Serial":       now.Format("2006") + "-SAMPLE",
+        "IssuedAt":     certDate{Time: now, Locale: locale},
+        "EventName":    event.EventName,
+        "EventSpeaker": event.EventSpeaker,
+        "EventStart":   certDate{Time: event.EventDStart, Locale: locale},
+        "EventEnd":     certDate{Time: event.EventDEnd, Locale: locale},
+        "UserName":     "Jane Doe",
+        "UserInstance": "Sample University",
+        "VerifyURL":    publicURL(backend, "api/certificate-verify/sample"),
+        "VerifyQR":     template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(qr)),
+    }
+}
+
+func certificateSampleQR(backend *Backend) ([]byte, error) {
+    return qrcode.Encode(publicURL(backend, "api/certificate-verify/sample"), qrcode.Medium, 512)
+}
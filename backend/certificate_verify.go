@@ -0,0 +1,86 @@
+package main
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "webrpl/table"
+
+    "github.com/skip2/go-qrcode"
+)
+
+var errCertInvalid = errors.New("certificate code is invalid")
+
+// NOTE: The verify code is `<participant id>.<signature>`. The participant
+//       code is not in it on purpose, the qr is printed on the certificate and
+//       should not give away the link to download it. Changing the secret
+//       invalidate every code already printed. The date signed is
+//       CertSignedAt, set on the first issue and carried over by a reissue,
+//       so neither a reissue nor an edit of the event break a printed qr.
+func certificateSignature(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate) string {
+    mac := hmac.New(sha256.New, []byte(backend.pass))
+    fmt.Fprintf(mac, "certificate\n%d\n%d\n%d\n%d", evPart.ID, evPart.UserId, evPart.EventId, cert.CertSignedAt.Unix())
+    return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:18])
+}
+
+func certificateVerifyCode(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate) string {
+    return fmt.Sprintf("%d.%s", evPart.ID, certificateSignature(backend, evPart, cert))
+}
+
+func certificateVerifyURL(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate) string {
+    return publicURL(backend, "api/certificate-verify/"+certificateVerifyCode(backend, evPart, cert))
+}
+
+func certificateQRURL(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate) string {
+    return certificateVerifyURL(backend, evPart, cert) + "/qr.png"
+}
+
+// Return errCertInvalid for a malformed or forged code and for a participant
+// that did not get a certificate (yet), errCertRevoked with the participant
+// and the certificate when it was revoked, anything else is a db error.
+func findVerifiedCertificate(backend *Backend, code string) (*table.EventParticipant, *table.Certificate, error) {
+    idStr, sig, ok := strings.Cut(code, ".")
+    if !ok {
+        return nil, nil, errCertInvalid
+    }
+    id, err := strconv.Atoi(idStr)
+    if err != nil || id <= 0 {
+        return nil, nil, errCertInvalid
+    }
+
+    var evPart table.EventParticipant
+    res := backend.db.Preload("User").Preload("Event").
+        Where("id = ? AND eventp_come = ?", id, true).
+        Limit(1).Find(&evPart)
+    if res.Error != nil {
+        return nil, nil, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return nil, nil, errCertInvalid
+    }
+
+    // Every revision carry the same CertSignedAt, the latest is enough. Nothing
+    // issued yet mean no code was ever given out.
+    cert, err := latestCertificate(backend, evPart.ID)
+    if err != nil {
+        return nil, nil, err
+    }
+    if cert == nil {
+        return nil, nil, errCertInvalid
+    }
+    if !hmac.Equal([]byte(sig), []byte(certificateSignature(backend, &evPart, cert))) {
+        return nil, nil, errCertInvalid
+    }
+    if cert.CertRevokedAt != nil {
+        return &evPart, cert, errCertRevoked
+    }
+    return &evPart, cert, nil
+}
+
+func certificateQR(backend *Backend, evPart *table.EventParticipant, cert *table.Certificate, size int) ([]byte, error) {
+    return qrcode.Encode(certificateVerifyURL(backend, evPart, cert), qrcode.Medium, size)
+}
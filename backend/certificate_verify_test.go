@@ -0,0 +1,102 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+    "webrpl/table"
+)
+
+func TestCertificateVerifyCode(t *testing.T) {
+    db := openTestDB(t, ":memory:")
+    backend := &Backend{db: db, pass: "test-secret"}
+
+    end := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+    event := table.Event{EventName: "Verify", EventMax: 10, EventDStart: end.Add(-2 * time.Hour), EventDEnd: end}
+    if err := db.Create(&event).Error; err != nil {
+        t.Fatalf("create event: %v", err)
+    }
+    var parts []*table.EventParticipant
+    for i := range 2 {
+        user := table.User{UserEmail: fmt.Sprintf("verify-%d@example.com", i)}
+        if err := db.Create(&user).Error; err != nil {
+            t.Fatalf("create user: %v", err)
+        }
+        part := &table.EventParticipant{
+            EventId:      event.ID,
+            UserId:       user.ID,
+            EventPRole:   table.NormalU,
+            EventPCome:   true,
+            EventPCode:   newParticipantCode(),
+            EventPStatus: table.RegisteredP,
+        }
+        if err := db.Create(part).Error; err != nil {
+            t.Fatalf("create participant: %v", err)
+        }
+        part.Event = event
+        parts = append(parts, part)
+    }
+
+    cert, err := currentCertificate(backend, parts[0])
+    if err != nil {
+        t.Fatalf("issue certificate: %v", err)
+    }
+    code := certificateVerifyCode(backend, parts[0], cert)
+
+    verify := func(code string) error {
+        _, _, err := findVerifiedCertificate(backend, code)
+        return err
+    }
+    if err := verify(code); err != nil {
+        t.Fatalf("verify = %v, want nil", err)
+    }
+
+    // The end date is not what is signed, moving it keep the printed code.
+    res := db.Model(&table.Event{}).Where("id = ?", event.ID).Update("event_dend", end.Add(-time.Hour))
+    if res.Error != nil {
+        t.Fatalf("edit event: %v", res.Error)
+    }
+    if err := verify(code); err != nil {
+        t.Fatalf("verify after editing the event end = %v, want nil", err)
+    }
+
+    // A reissue carry the signed date over.
+    if _, err := reissueCertificate(backend, parts[0].ID); err != nil {
+        t.Fatalf("reissue: %v", err)
+    }
+    if err := verify(code); err != nil {
+        t.Fatalf("verify after a reissue = %v, want nil", err)
+    }
+
+    if _, err := currentCertificate(backend, parts[1]); err != nil {
+        t.Fatalf("issue second certificate: %v", err)
+    }
+    tampered := code[:len(code)-1] + "A"
+    if code[len(code)-1] == 'A' {
+        tampered = code[:len(code)-1] + "B"
+    }
+    id, sig := fmt.Sprint(parts[0].ID), code[len(fmt.Sprint(parts[0].ID))+1:]
+    tests := []struct {
+        name string
+        code string
+    }{
+        {"tampered signature", tampered},
+        {"signature of another participant", fmt.Sprintf("%d.%s", parts[1].ID, sig)},
+        {"no signature", id},
+        {"empty signature", id + "."},
+        {"not a number", "x." + sig},
+        {"unknown participant", "999999." + sig},
+        {"empty", ""},
+    }
+    for _, tt := range tests {
+        if err := verify(tt.code); !errors.Is(err, errCertInvalid) {
+            t.Errorf("%s: verify(%q) = %v, want errCertInvalid", tt.name, tt.code, err)
+        }
+    }
+
+    other := &Backend{db: db, pass: "another-secret"}
+    if _, _, err := findVerifiedCertificate(other, code); !errors.Is(err, errCertInvalid) {
+        t.Errorf("verify with another secret = %v, want errCertInvalid", err)
+    }
+}
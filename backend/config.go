@@ -0,0 +1,422 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "net"
+    "net/url"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/robfig/cron/v3"
+    "gopkg.in/yaml.v3"
+)
+
+const defaultJWTSecret = "secret"
+
+type UploadConfig struct {
+    StaticDir string `yaml:"static_dir"`
+    HiddenDir string `yaml:"hidden_dir"`
+    CacheDir  string `yaml:"cache_dir"`
+    // NOTE: Shared `{{ define }}` for every template the engine render, one
+    //       file one partial named after the file.
+    PartialsDir string `yaml:"partials_dir"`
+}
+
+type CORSConfig struct {
+    AllowOrigins []string `yaml:"allow_origins"`
+}
+
+type AuthConfig struct {
+    AccessTTL  time.Duration `yaml:"access_ttl"`
+    RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+type OTPConfig struct {
+    Length int           `yaml:"length"`
+    TTL    time.Duration `yaml:"ttl"`
+}
+
+// NOTE: The spec is the standard 5 field cron or a descriptor like `@every 10m`
+//       and `@daily`. Empty disable the schedule, the job can still be run by hand.
+type JobsConfig struct {
+    OTPCleanup      string        `yaml:"otp_cleanup"`
+    SessionCleanup  string        `yaml:"session_cleanup"`
+    OrphanCleanup   string        `yaml:"orphan_cleanup"`
+    EventReminder   string        `yaml:"event_reminder"`
+    CertIssue       string        `yaml:"certificate_issue"`
+    CertEmail       string        `yaml:"certificate_email"`
+    SessionKeep     time.Duration `yaml:"session_keep"`
+    OrphanGrace     time.Duration `yaml:"orphan_grace"`
+    ReminderBefore  time.Duration `yaml:"reminder_before"`
+    // NOTE: Only the event that ended less than this ago are mailed by the
+    //       job, so turning it on dont mail every event ever held.
+    CertEmailWithin time.Duration `yaml:"certificate_email_within"`
+    CertEmailAttach bool          `yaml:"certificate_email_attach"`
+}
+
+// NOTE: A request is refused when either the ip or the email go over its
+//       limit on the window, 0 turn that side off.
+type RateLimitConfig struct {
+    PerIP    int           `yaml:"per_ip"`
+    PerEmail int           `yaml:"per_email"`
+    Window   time.Duration `yaml:"window"`
+}
+
+type SecurityConfig struct {
+    ProxyHeader      string          `yaml:"proxy_header"`
+    LoginLimit       RateLimitConfig `yaml:"login_limit"`
+    OTPLimit         RateLimitConfig `yaml:"otp_limit"`
+    VerifyLimit      RateLimitConfig `yaml:"verify_limit"`
+    MaxLoginFailures int             `yaml:"max_login_failures"`
+    LockoutDuration  time.Duration   `yaml:"lockout_duration"`
+    MaxOTPFailures   int             `yaml:"max_otp_failures"`
+}
+
+// NOTE: Loaded from config.yaml (or WRPL_CONFIG) first, then the WRPL_* env
+//       override whatever is on the file. See config.example.yaml.
+type Config struct {
+    Mode          string         `yaml:"mode"`
+    ListenAddress string         `yaml:"listen_address"`
+    PublicBaseURL string         `yaml:"public_base_url"`
+    Locale        string         `yaml:"locale"`
+    JWTSecret     string         `yaml:"jwt_secret"`
+    AdminPassword string         `yaml:"admin_password"`
+    Auth          AuthConfig     `yaml:"auth"`
+    Security      SecurityConfig `yaml:"security"`
+    OTP           OTPConfig      `yaml:"otp"`
+    Jobs          JobsConfig     `yaml:"jobs"`
+    DB            DBConfig       `yaml:"db"`
+    Mail          MailConfig     `yaml:"mail"`
+    Upload        UploadConfig   `yaml:"upload"`
+    CORS          CORSConfig     `yaml:"cors"`
+}
+
+func defaultConfig() Config {
+    return Config{
+        Mode:          "prod",
+        ListenAddress: "0.0.0.0:3000",
+        Locale:        "en",
+        JWTSecret:     defaultJWTSecret,
+        Auth: AuthConfig{
+            AccessTTL:  15 * time.Minute,
+            RefreshTTL: 30 * 24 * time.Hour,
+        },
+        Security: SecurityConfig{
+            LoginLimit:       RateLimitConfig{PerIP: 30, PerEmail: 10, Window: time.Minute},
+            OTPLimit:         RateLimitConfig{PerIP: 20, PerEmail: 5, Window: 15 * time.Minute},
+            VerifyLimit:      RateLimitConfig{PerIP: 30, PerEmail: 10, Window: time.Minute},
+            MaxLoginFailures: 5,
+            LockoutDuration:  15 * time.Minute,
+            MaxOTPFailures:   5,
+        },
+        OTP: OTPConfig{
+            Length: 6,
+            TTL:    5 * time.Minute,
+        },
+        Jobs: JobsConfig{
+            OTPCleanup:      "@every 10m",
+            SessionCleanup:  "@hourly",
+            OrphanCleanup:   "30 3 * * *",
+            EventReminder:   "*/15 * * * *",
+            CertIssue:       "*/10 * * * *",
+            SessionKeep:     7 * 24 * time.Hour,
+            OrphanGrace:     24 * time.Hour,
+            ReminderBefore:  24 * time.Hour,
+            CertEmailWithin: 72 * time.Hour,
+        },
+        DB: DBConfig{
+            Driver: "sqlite",
+            DSN:    "./db/data.db",
+        },
+        Mail: MailConfig{
+            Driver:  "smtp",
+            Host:    "smtp.gmail.com",
+            Port:    587,
+            TLSMode: MailTLSStartTLS,
+            Dir:     "./mail",
+        },
+        Upload: UploadConfig{
+            StaticDir: "./static",
+            HiddenDir: "./static-hidden",
+            CacheDir:  "./cache",
+            PartialsDir: "./static-hidden/partials",
+        },
+        CORS: CORSConfig{
+            AllowOrigins: []string{"*"},
+        },
+    }
+}
+
+func loadConfig() (*Config, error) {
+    conf := defaultConfig()
+
+    path := os.Getenv("WRPL_CONFIG")
+    explicit := path != ""
+    if !explicit {
+        path = "config.yaml"
+    }
+
+    raw, err := os.ReadFile(path)
+    if err != nil {
+        if explicit || !os.IsNotExist(err) {
+            return nil, fmt.Errorf("failed to read config %s, %w", path, err)
+        }
+    } else {
+        decoder := yaml.NewDecoder(bytes.NewReader(raw))
+        decoder.KnownFields(true)
+        if err := decoder.Decode(&conf); err != nil {
+            return nil, fmt.Errorf("failed to parse config %s, %w", path, err)
+        }
+    }
+
+    applyConfigEnv(&conf)
+
+    if conf.PublicBaseURL == "" {
+        conf.PublicBaseURL = "http://" + conf.ListenAddress
+    }
+    conf.PublicBaseURL = strings.TrimRight(conf.PublicBaseURL, "/")
+    if conf.AdminPassword == "" {
+        conf.AdminPassword = conf.JWTSecret
+    }
+    if conf.Mail.Username == "" {
+        conf.Mail.Username = conf.Mail.From
+    }
+    if conf.Mail.From == "" {
+        conf.Mail.From = conf.Mail.Username
+    }
+
+    if err := conf.Validate(); err != nil {
+        return nil, err
+    }
+    return &conf, nil
+}
+
+func applyConfigEnv(conf *Config) {
+    setString := func(key string, dst *string) {
+        if v := os.Getenv(key); v != "" {
+            *dst = v
+        }
+    }
+    setInt := func(key string, dst *int) {
+        if v := os.Getenv(key); v != "" {
+            if i, err := strconv.Atoi(v); err == nil {
+                *dst = i
+            }
+        }
+    }
+    setDuration := func(key string, dst *time.Duration) {
+        if v := os.Getenv(key); v != "" {
+            if d, err := time.ParseDuration(v); err == nil {
+                *dst = d
+            }
+        }
+    }
+
+    setString("WRPL_MODE", &conf.Mode)
+    setString("WRPL_PUBLIC_URL", &conf.PublicBaseURL)
+    setString("WRPL_LOCALE", &conf.Locale)
+    setString("WRPL_SECRET", &conf.JWTSecret)
+    setString("WRPL_ADMIN_PASS", &conf.AdminPassword)
+    setDuration("WRPL_ACCESS_TTL", &conf.Auth.AccessTTL)
+    setDuration("WRPL_REFRESH_TTL", &conf.Auth.RefreshTTL)
+
+    setString("WRPL_PROXY_HEADER", &conf.Security.ProxyHeader)
+    setInt("WRPL_MAX_LOGIN_FAILURES", &conf.Security.MaxLoginFailures)
+    setDuration("WRPL_LOCKOUT_DURATION", &conf.Security.LockoutDuration)
+    setInt("WRPL_MAX_OTP_FAILURES", &conf.Security.MaxOTPFailures)
+    setInt("WRPL_OTP_LENGTH", &conf.OTP.Length)
+    setDuration("WRPL_OTP_TTL", &conf.OTP.TTL)
+    setDuration("WRPL_REMINDER_BEFORE", &conf.Jobs.ReminderBefore)
+
+    // NOTE: WRPL_IP and WRPL_PORT are the old way, still work one by one.
+    host, port, err := net.SplitHostPort(conf.ListenAddress)
+    if err != nil {
+        host, port = "0.0.0.0", "3000"
+    }
+    setString("WRPL_IP", &host)
+    if v := os.Getenv("WRPL_PORT"); v != "" {
+        if _, err := strconv.Atoi(v); err == nil {
+            port = v
+        }
+    }
+    conf.ListenAddress = net.JoinHostPort(host, port)
+    setString("WRPL_LISTEN", &conf.ListenAddress)
+
+    setString("WRPL_DB_DRIVER", &conf.DB.Driver)
+    setString("WRPL_DB_DSN", &conf.DB.DSN)
+    conf.DB.Driver = strings.ToLower(conf.DB.Driver)
+
+    // NOTE: WRPL_EMAIL and WRPL_EMAPPPASS are the old gmail setting.
+    setString("WRPL_EMAIL", &conf.Mail.From)
+    setString("WRPL_EMAIL", &conf.Mail.Username)
+    setString("WRPL_EMAPPPASS", &conf.Mail.Password)
+    setString("WRPL_MAIL_DRIVER", &conf.Mail.Driver)
+    setString("WRPL_MAIL_FROM", &conf.Mail.From)
+    setString("WRPL_MAIL_DIR", &conf.Mail.Dir)
+    setString("WRPL_SMTP_HOST", &conf.Mail.Host)
+    setString("WRPL_SMTP_USER", &conf.Mail.Username)
+    setString("WRPL_SMTP_PASS", &conf.Mail.Password)
+    if v := os.Getenv("WRPL_SMTP_PORT"); v != "" {
+        if p, err := strconv.Atoi(v); err == nil {
+            conf.Mail.Port = p
+        }
+    }
+    if v := os.Getenv("WRPL_SMTP_TLS"); v != "" {
+        conf.Mail.TLSMode = MailTLSMode(v)
+    }
+    conf.Mail.Driver = strings.ToLower(conf.Mail.Driver)
+    conf.Mail.TLSMode = MailTLSMode(strings.ToLower(string(conf.Mail.TLSMode)))
+
+    setString("WRPL_STATIC_DIR", &conf.Upload.StaticDir)
+    setString("WRPL_HIDDEN_DIR", &conf.Upload.HiddenDir)
+    setString("WRPL_CACHE_DIR", &conf.Upload.CacheDir)
+    setString("WRPL_PARTIALS_DIR", &conf.Upload.PartialsDir)
+
+    if v := os.Getenv("WRPL_CORS_ORIGINS"); v != "" {
+        origins := []string{}
+        for _, origin := range strings.Split(v, ",") {
+            if origin = strings.TrimSpace(origin); origin != "" {
+                origins = append(origins, origin)
+            }
+        }
+        conf.CORS.AllowOrigins = origins
+    }
+}
+
+func (conf *Config) IsDev() bool {
+    return conf.Mode == "dev"
+}
+
+func (conf *Config) SecureCookie() bool {
+    return strings.HasPrefix(conf.PublicBaseURL, "https://")
+}
+
+// NOTE: Collect every problem at once so it dont need to be restarted 10 times.
+func (conf *Config) Validate() error {
+    var errs []error
+
+    if conf.Mode != "dev" && conf.Mode != "prod" {
+        errs = append(errs, fmt.Errorf("mode must be `dev` or `prod`, got %q", conf.Mode))
+    }
+
+    if _, port, err := net.SplitHostPort(conf.ListenAddress); err != nil {
+        errs = append(errs, fmt.Errorf("invalid listen_address %q, %v", conf.ListenAddress, err))
+    } else if _, err := strconv.Atoi(port); err != nil {
+        errs = append(errs, fmt.Errorf("invalid listen_address port %q", port))
+    }
+
+    if u, err := url.Parse(conf.PublicBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        errs = append(errs, fmt.Errorf("public_base_url must be an absolute http(s) url, got %q", conf.PublicBaseURL))
+    }
+
+    if _, ok := certLocales[conf.Locale]; !ok {
+        errs = append(errs, fmt.Errorf("locale must be `en` or `id`, got %q", conf.Locale))
+    }
+
+    if conf.JWTSecret == "" {
+        errs = append(errs, errors.New("jwt_secret must not be empty"))
+    } else if !conf.IsDev() && conf.JWTSecret == defaultJWTSecret {
+        errs = append(errs, errors.New("refusing to run with the default jwt_secret outside dev mode, set WRPL_SECRET or use WRPL_MODE=dev"))
+    }
+
+    if conf.Auth.AccessTTL <= 0 || conf.Auth.RefreshTTL <= 0 {
+        errs = append(errs, errors.New("auth access_ttl and refresh_ttl must be > 0"))
+    } else if conf.Auth.AccessTTL >= conf.Auth.RefreshTTL {
+        errs = append(errs, errors.New("auth access_ttl must be shorter than refresh_ttl"))
+    }
+
+    limits := []struct {
+        name  string
+        limit RateLimitConfig
+    }{
+        {"login_limit", conf.Security.LoginLimit},
+        {"otp_limit", conf.Security.OTPLimit},
+        {"verify_limit", conf.Security.VerifyLimit},
+    }
+    for _, l := range limits {
+        name, limit := l.name, l.limit
+        if limit.PerIP < 0 || limit.PerEmail < 0 {
+            errs = append(errs, fmt.Errorf("security %s per_ip and per_email must be >= 0", name))
+        }
+        if (limit.PerIP > 0 || limit.PerEmail > 0) && limit.Window <= 0 {
+            errs = append(errs, fmt.Errorf("security %s window must be > 0", name))
+        }
+    }
+    if conf.Security.MaxLoginFailures < 0 || conf.Security.MaxOTPFailures < 0 {
+        errs = append(errs, errors.New("security max_login_failures and max_otp_failures must be >= 0"))
+    }
+    if conf.Security.MaxLoginFailures > 0 && conf.Security.LockoutDuration <= 0 {
+        errs = append(errs, errors.New("security lockout_duration must be > 0"))
+    }
+
+    if conf.OTP.Length < 4 || conf.OTP.Length > 32 {
+        errs = append(errs, fmt.Errorf("otp length must be between 4 and 32, got %d", conf.OTP.Length))
+    }
+    if conf.OTP.TTL <= 0 {
+        errs = append(errs, errors.New("otp ttl must be > 0"))
+    }
+
+    specs := []struct {
+        name string
+        spec string
+    }{
+        {"otp_cleanup", conf.Jobs.OTPCleanup},
+        {"session_cleanup", conf.Jobs.SessionCleanup},
+        {"orphan_cleanup", conf.Jobs.OrphanCleanup},
+        {"event_reminder", conf.Jobs.EventReminder},
+        {"certificate_issue", conf.Jobs.CertIssue},
+        {"certificate_email", conf.Jobs.CertEmail},
+    }
+    for _, s := range specs {
+        if s.spec == "" {
+            continue
+        }
+        if _, err := cron.ParseStandard(s.spec); err != nil {
+            errs = append(errs, fmt.Errorf("invalid jobs %s spec %q, %v", s.name, s.spec, err))
+        }
+    }
+    if conf.Jobs.SessionKeep < 0 || conf.Jobs.OrphanGrace <= 0 || conf.Jobs.ReminderBefore <= 0 || conf.Jobs.CertEmailWithin <= 0 {
+        errs = append(errs, errors.New("jobs session_keep must be >= 0, orphan_grace, reminder_before and certificate_email_within must be > 0"))
+    }
+
+    switch conf.DB.Driver {
+    case "sqlite", "sqlite3", "postgres", "postgresql", "mysql", "mariadb":
+    default:
+        errs = append(errs, fmt.Errorf("unknown db driver %q", conf.DB.Driver))
+    }
+    if conf.DB.DSN == "" {
+        errs = append(errs, errors.New("db dsn must not be empty"))
+    }
+
+    switch conf.Mail.Driver {
+    case "smtp":
+        if conf.Mail.Host == "" || conf.Mail.Port <= 0 {
+            errs = append(errs, errors.New("mail host and port must be set for the smtp driver"))
+        }
+        switch conf.Mail.TLSMode {
+        case MailTLSStartTLS, MailTLSImplicit, MailTLSNone:
+        default:
+            errs = append(errs, fmt.Errorf("invalid mail tls mode %q", conf.Mail.TLSMode))
+        }
+    case "file":
+        if conf.Mail.Dir == "" {
+            errs = append(errs, errors.New("mail dir must be set for the file driver"))
+        }
+    default:
+        errs = append(errs, fmt.Errorf("unknown mail driver %q", conf.Mail.Driver))
+    }
+
+    if conf.Upload.StaticDir == "" || conf.Upload.HiddenDir == "" || conf.Upload.CacheDir == "" {
+        errs = append(errs, errors.New("upload static_dir, hidden_dir and cache_dir must be set"))
+    }
+
+    if len(conf.CORS.AllowOrigins) == 0 {
+        errs = append(errs, errors.New("cors allow_origins must have at least one origin"))
+    }
+
+    return errors.Join(errs...)
+}
@@ -1,50 +1,99 @@
 package main
 
 import (
+    "fmt"
     "log"
+    "strings"
+    "gorm.io/driver/mysql"
+    "gorm.io/driver/postgres"
     "gorm.io/driver/sqlite"
     "gorm.io/gorm"
-    "webrpl/table"
+    "webrpl/migration"
 )
 
-func open_db(dbFile string) (*gorm.DB, error) {
-    db, err := gorm.Open(sqlite.Open(dbFile), &gorm.Config{})
+// NOTE: Driver is `sqlite` (default), `postgres` or `mysql`.
+//       DSN is the file path for sqlite, the connection string for the rest.
+type DBConfig struct {
+    Driver string `yaml:"driver"`
+    DSN    string `yaml:"dsn"`
+}
+
+func open_db(conf DBConfig) (*gorm.DB, error) {
+    var dialector gorm.Dialector
+    switch conf.Driver {
+    case "sqlite", "sqlite3":
+        dialector = sqlite.Open(conf.DSN)
+    case "postgres", "postgresql":
+        dialector = postgres.Open(conf.DSN)
+    case "mysql", "mariadb":
+        dialector = mysql.Open(conf.DSN)
+    default:
+        return nil, fmt.Errorf("unknown db driver %q", conf.Driver)
+    }
+
+    db, err := gorm.Open(dialector, &gorm.Config{})
     if err != nil {
         return nil, err
     }
+
+    // Every new connection to `:memory:` is a brand new db, so keep only one.
+    if dialector.Name() == "sqlite" && strings.Contains(conf.DSN, ":memory:") {
+        sqlDB, err := db.DB()
+        if err != nil {
+            return nil, err
+        }
+        sqlDB.SetMaxOpenConns(1)
+    }
     return db, nil
 }
 
+// NOTE: Schema changes live on `webrpl/migration`, add a new step there
+//       instead of calling AutoMigrate from here.
 func migrate_db(db *gorm.DB) error {
-    err := db.AutoMigrate(&table.User{})
+    count, err := migration.Up(db)
     if err != nil {
-        log.Fatal("failed to migrate database:", err)
+        log.Println("failed to migrate database:", err)
         return err
     }
-    err = db.AutoMigrate(&table.Event{})
-    if err != nil {
-        log.Fatal("failed to migrate database:", err)
-        return err
-    }
-    err = db.AutoMigrate(&table.OTP{})
-    if err != nil {
-        log.Fatal("failed to migrate database:", err)
-        return err
+    if count > 0 {
+        log.Printf("INFO: Applied %d migration(s).", count)
     }
-    err = db.AutoMigrate(&table.EventParticipant{})
-    if err != nil {
-        log.Fatal("failed to migrate database:", err)
-        return err
+    return nil
+}
+
+// NOTE: Usage: webrpl migrate status|up|down [steps]
+func run_migrate_cli(db *gorm.DB, args []string) error {
+    cmd := "status"
+    if len(args) > 0 {
+        cmd = args[0]
     }
-    err = db.AutoMigrate(&table.EventMaterial{})
-    if err != nil {
-        log.Fatal("failed to migrate database:", err)
+
+    switch cmd {
+    case "status":
+        list, err := migration.List(db)
+        if err != nil {
+            return err
+        }
+        for _, m := range list {
+            state := "pending"
+            if m.Applied {
+                state = fmt.Sprintf("applied %s", m.AppliedAt.Format("2006-01-02 15:04:05"))
+            }
+            fmt.Printf("%s  %-24s %s\n", m.Version, m.Name, state)
+        }
+        return nil
+    case "up":
+        _, err := migration.Up(db)
         return err
-    }
-    err = db.AutoMigrate(&table.CertTemplate{})
-    if err != nil {
-        log.Fatal("failed to migrate database:", err)
+    case "down":
+        steps := 1
+        if len(args) > 1 {
+            if _, err := fmt.Sscanf(args[1], "%d", &steps); err != nil {
+                return fmt.Errorf("invalid steps %q", args[1])
+            }
+        }
+        _, err := migration.Down(db, steps)
         return err
     }
-    return nil
+    return fmt.Errorf("unknown migrate command %q, use status, up or down", cmd)
 }
@@ -0,0 +1,176 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+    "webrpl/migration"
+    "webrpl/table"
+
+    "gorm.io/gorm"
+)
+
+// Open a fresh sqlite db and run every migration on it. `:memory:` is kept to
+// one connection by open_db, give a file on t.TempDir() for a test that need
+// more than one.
+func openTestDB(t *testing.T, dsn string) *gorm.DB {
+    t.Helper()
+
+    db, err := open_db(DBConfig{Driver: "sqlite", DSN: dsn})
+    if err != nil {
+        t.Fatalf("open_db(%q): %v", dsn, err)
+    }
+    sqlDB, err := db.DB()
+    if err != nil {
+        t.Fatalf("db.DB(): %v", err)
+    }
+    t.Cleanup(func() { sqlDB.Close() })
+
+    if err := migrate_db(db); err != nil {
+        t.Fatalf("migrate_db: %v", err)
+    }
+    return db
+}
+
+func TestMemoryDBMigrate(t *testing.T) {
+    db := openTestDB(t, ":memory:")
+
+    sqlDB, _ := db.DB()
+    if max := sqlDB.Stats().MaxOpenConnections; max != 1 {
+        t.Fatalf("MaxOpenConnections = %d, want 1 for :memory:", max)
+    }
+
+    list, err := migration.List(db)
+    if err != nil {
+        t.Fatalf("migration.List: %v", err)
+    }
+    for _, m := range list {
+        if !m.Applied {
+            t.Errorf("migration %s_%s is not applied", m.Version, m.Name)
+        }
+    }
+
+    for _, model := range []any{
+        &table.User{}, &table.Event{}, &table.EventParticipant{}, &table.Certificate{},
+        &table.Session{}, &table.EmailOutbox{},
+    } {
+        if !db.Migrator().HasTable(model) {
+            t.Errorf("table of %T is missing", model)
+        }
+    }
+
+    // Again on an up to date db is a no-op.
+    if count, err := migration.Up(db); err != nil || count != 0 {
+        t.Fatalf("migration.Up again = %d, %v, want 0, nil", count, err)
+    }
+}
+
+// NOTE: The time column have no `type:datetime` anymore, they must still come
+//       back the same on sqlite.
+func TestMemoryDBTimeRoundTrip(t *testing.T) {
+    db := openTestDB(t, ":memory:")
+
+    start := time.Date(2030, 10, 1, 10, 0, 0, 0, time.UTC)
+    event := table.Event{
+        EventName:   "Round Trip",
+        EventMax:    1,
+        EventDStart: start,
+        EventDEnd:   start.Add(time.Hour),
+    }
+    if err := db.Create(&event).Error; err != nil {
+        t.Fatalf("create event: %v", err)
+    }
+
+    var got table.Event
+    if err := db.First(&got, event.ID).Error; err != nil {
+        t.Fatalf("read event: %v", err)
+    }
+    if !got.EventDStart.Equal(event.EventDStart) || !got.EventDEnd.Equal(event.EventDEnd) {
+        t.Fatalf("dates = %v - %v, want %v - %v", got.EventDStart, got.EventDEnd, event.EventDStart, event.EventDEnd)
+    }
+}
+
+// The already registered check run on sqlite in memory too.
+func TestMemoryDBRegisterTwice(t *testing.T) {
+    db := openTestDB(t, ":memory:")
+    backend := &Backend{db: db}
+
+    event := table.Event{EventName: "Twice", EventMax: 10, EventDStart: time.Now(), EventDEnd: time.Now()}
+    user := table.User{UserEmail: "twice@example.com"}
+    if err := db.Create(&event).Error; err != nil {
+        t.Fatalf("create event: %v", err)
+    }
+    if err := db.Create(&user).Error; err != nil {
+        t.Fatalf("create user: %v", err)
+    }
+
+    if _, err := registerParticipant(backend, event.ID, user.ID, table.NormalU, nil, false); err != nil {
+        t.Fatalf("first registration: %v", err)
+    }
+    _, err := registerParticipant(backend, event.ID, user.ID, table.NormalU, nil, false)
+    if !errors.Is(err, errAlreadyRegistered) {
+        t.Fatalf("second registration = %v, want errAlreadyRegistered", err)
+    }
+}
+
+// Every step can go down and up again on sqlite.
+func TestMemoryDBMigrateDownUp(t *testing.T) {
+    db := openTestDB(t, ":memory:")
+
+    list, err := migration.List(db)
+    if err != nil {
+        t.Fatalf("migration.List: %v", err)
+    }
+    count, err := migration.Down(db, len(list))
+    if err != nil || count != len(list) {
+        t.Fatalf("migration.Down = %d, %v, want %d, nil", count, err, len(list))
+    }
+    if db.Migrator().HasTable(&table.User{}) {
+        t.Fatalf("users table is still there after going all the way down")
+    }
+
+    count, err = migration.Up(db)
+    if err != nil || count != len(list) {
+        t.Fatalf("migration.Up = %d, %v, want %d, nil", count, err, len(list))
+    }
+}
+
+// NOTE: A Down that drop a column must not take the index of the table with
+//       it, the unique one are what stop a double registration or issue.
+func TestMemoryDBMigrateKeepIndexes(t *testing.T) {
+    db := openTestDB(t, ":memory:")
+
+    indexes := []string{
+        "idx_event_participants_event_user",
+        "idx_event_participants_event_p_code",
+        "idx_certificates_participant_revision",
+        "idx_certificates_cert_serial",
+    }
+    check := func(step string) {
+        t.Helper()
+        for _, name := range indexes {
+            var count int64
+            db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?", name).Scan(&count)
+            if count != 1 {
+                t.Errorf("%s: index %s is missing", step, name)
+            }
+        }
+    }
+    check("fresh")
+
+    list, err := migration.List(db)
+    if err != nil {
+        t.Fatalf("migration.List: %v", err)
+    }
+    // Only down to the one after 0013, where the last of them is made.
+    for steps := 1; steps <= len(list)-13; steps++ {
+        if _, err := migration.Down(db, steps); err != nil {
+            t.Fatalf("migration.Down(%d): %v", steps, err)
+        }
+        if _, err := migration.Up(db); err != nil {
+            t.Fatalf("migration.Up after %d down: %v", steps, err)
+        }
+        check(fmt.Sprintf("after %d down", steps))
+    }
+}
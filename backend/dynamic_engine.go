@@ -6,10 +6,34 @@ import (
     "io"
     "os"
     "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
     "sync"
+    "sync/atomic"
     "time"
 )
 
+// NOTE: One parsed template and the file it came from, it is only parsed
+//       again when the mtime or size of that file change (or the partials),
+//       so saving one event's certificate dont throw away the other.
+type engineEntry struct {
+    tmpl       *template.Template
+    path       string
+    modTime    time.Time
+    size       int64
+    generation uint64
+}
+
+type EngineStats struct {
+    Hits     int64 `json:"hits"`
+    Misses   int64 `json:"misses"`
+    Reloads  int64 `json:"reloads"`
+    Errors   int64 `json:"errors"`
+    Cached   int   `json:"cached"`
+    Partials int   `json:"partials"`
+}
+
 type DynamicEngine struct {
     directories []string
     extension   string
@@ -17,21 +41,31 @@ type DynamicEngine struct {
     left        string
     right       string
     mutex       sync.RWMutex
-    cache       map[string]*template.Template
-    cacheTime   map[string]time.Time
-    cacheTTL    time.Duration
+    cache       map[string]*engineEntry
+
+    // Every *.html on partialsDir is defined (by its base name) on every
+    // template, so `{{ template "footer" . }}` work anywhere.
+    partialsDir string
+    partials    map[string]string
+    partialsSig string
+    generation  uint64
+
+    hits    atomic.Int64
+    misses  atomic.Int64
+    reloads atomic.Int64
+    errors  atomic.Int64
 }
 
-func NewDynamicEngine(directories []string, extension string) *DynamicEngine {
+func NewDynamicEngine(directories []string, extension string, partialsDir string) *DynamicEngine {
     return &DynamicEngine{
         directories: directories,
         extension: extension,
         funcMap:   make(template.FuncMap),
         left:      "{{",
         right:     "}}",
-        cache:     make(map[string]*template.Template),
-        cacheTime: make(map[string]time.Time),
-        cacheTTL:  5 * time.Minute, // Cache templates for 5 minutes
+        cache:     make(map[string]*engineEntry),
+        partialsDir: partialsDir,
+        partials:    make(map[string]string),
     }
 }
 
@@ -39,6 +73,8 @@ func (e *DynamicEngine) AddFunc(name string, fn interface{}) {
     e.mutex.Lock()
     defer e.mutex.Unlock()
     e.funcMap[name] = fn
+    // Parsed with the old func map, parse again on the next render
+    e.generation++
 }
 
 func (e *DynamicEngine) Load() error {
@@ -53,83 +89,211 @@ func (e *DynamicEngine) Render(out io.Writer, name string, binding interface{},
     }
 
     if len(layout) > 0 && layout[0] != "" {
-        return e.renderWithLayout(out, name, binding, layout[0])
+        return e.renderWithLayout(out, tmpl, name, binding, layout[0])
     }
 
     return tmpl.Execute(out, binding)
 }
 
+func (e *DynamicEngine) findTemplate(name string) (string, os.FileInfo, error) {
+    for _, dir := range e.directories {
+        tryPath := filepath.Join(dir, name+e.extension)
+        if info, err := os.Stat(tryPath); err == nil && !info.IsDir() {
+            return tryPath, info, nil
+        }
+    }
+    return "", nil, fmt.Errorf("template %s does not exist in any directories", name)
+}
+
 func (e *DynamicEngine) getTemplate(name string) (*template.Template, error) {
-    e.mutex.RLock()
+    if err := e.refreshPartials(); err != nil {
+        e.errors.Add(1)
+        return nil, err
+    }
 
-    // Check cache and TTL
-    if tmpl, exists := e.cache[name]; exists {
-        if time.Since(e.cacheTime[name]) < e.cacheTTL {
-            e.mutex.RUnlock()
-            return tmpl, nil
-        }
+    templatePath, info, err := e.findTemplate(name)
+    if err != nil {
+        e.errors.Add(1)
+        e.ReloadTemplate(name)
+        return nil, err
     }
+
+    e.mutex.RLock()
+    entry := e.cache[name]
+    fresh := e.isFresh(entry, templatePath, info)
     e.mutex.RUnlock()
+    if fresh {
+        e.hits.Add(1)
+        return entry.tmpl, nil
+    }
 
     // Load template from disk
-    return e.loadTemplate(name)
+    return e.loadTemplate(name, templatePath, info)
 }
 
-func (e *DynamicEngine) loadTemplate(name string) (*template.Template, error) {
+// NOTE: Must be called with the mutex held.
+func (e *DynamicEngine) isFresh(entry *engineEntry, path string, info os.FileInfo) bool {
+    return entry != nil &&
+        entry.path == path &&
+        entry.modTime.Equal(info.ModTime()) &&
+        entry.size == info.Size() &&
+        entry.generation == e.generation
+}
+
+func (e *DynamicEngine) loadTemplate(name string, templatePath string, info os.FileInfo) (*template.Template, error) {
     e.mutex.Lock()
     defer e.mutex.Unlock()
 
     // Double-check after acquiring write lock
-    if tmpl, exists := e.cache[name]; exists {
-        if time.Since(e.cacheTime[name]) < e.cacheTTL {
-            return tmpl, nil
-        }
-    }
-
-    var templatePath string
-    var found bool
-    for _, dir := range e.directories {
-        tryPath := filepath.Join(dir, name+e.extension)
-        if _, err := os.Stat(tryPath); err == nil {
-            templatePath = tryPath
-            found = true
-            break
-        }
-    }
-    if !found {
-        return nil, fmt.Errorf("template %s does not exist in any directories", name)
-    }
-
-    // Check if file exists
-    if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-        return nil, fmt.Errorf("template %s does not exist", name)
+    entry, exists := e.cache[name]
+    if e.isFresh(entry, templatePath, info) {
+        e.hits.Add(1)
+        return entry.tmpl, nil
     }
 
     // Read template file
     content, err := os.ReadFile(templatePath)
     if err != nil {
+        e.errors.Add(1)
         return nil, fmt.Errorf("failed to read template %s: %w", name, err)
     }
 
-    // Create and parse template
-    tmpl := template.New(name).Delims(e.left, e.right).Funcs(e.funcMap)
-    tmpl, err = tmpl.Parse(string(content))
+    tmpl, err := e.parse(name, string(content))
     if err != nil {
+        e.errors.Add(1)
         return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
     }
 
+    if exists {
+        e.reloads.Add(1)
+    } else {
+        e.misses.Add(1)
+    }
+
     // Cache the template
-    e.cache[name] = tmpl
-    e.cacheTime[name] = time.Now()
+    e.cache[name] = &engineEntry{
+        tmpl:       tmpl,
+        path:       templatePath,
+        modTime:    info.ModTime(),
+        size:       info.Size(),
+        generation: e.generation,
+    }
 
     return tmpl, nil
 }
 
-func (e *DynamicEngine) renderWithLayout(out io.Writer, templateName string, binding interface{}, layoutName string) error {
-    // Load both template and layout
-    tmpl, err := e.getTemplate(templateName)
-    if err != nil {
-        return err
+// NOTE: Must be called with the mutex held. The partials are parsed first so
+//       a define on the template itself win over the shared one.
+func (e *DynamicEngine) parse(name string, content string) (*template.Template, error) {
+    tmpl := template.New(name).Delims(e.left, e.right).Funcs(e.funcMap)
+    for partial, source := range e.partials {
+        if partial == name {
+            continue
+        }
+        if _, err := tmpl.New(partial).Parse(source); err != nil {
+            return nil, fmt.Errorf("partial %s: %w", partial, err)
+        }
+    }
+    return tmpl.Parse(content)
+}
+
+// Parse a template that is not on the directories (like an upload that is
+// checked before it is saved) with the same funcs and partials.
+func (e *DynamicEngine) Parse(name string, content string) (*template.Template, error) {
+    if err := e.refreshPartials(); err != nil {
+        return nil, err
+    }
+
+    e.mutex.RLock()
+    defer e.mutex.RUnlock()
+    return e.parse(name, content)
+}
+
+// NOTE: The partials directory is listed on every render, a change on any of
+//       the file (or a new one) bump the generation so every template is
+//       parsed again with it. A missing directory is just no partial.
+func (e *DynamicEngine) refreshPartials() error {
+    if e.partialsDir == "" {
+        return nil
+    }
+
+    entries, err := os.ReadDir(e.partialsDir)
+    if err != nil && !os.IsNotExist(err) {
+        return fmt.Errorf("failed to read partials: %w", err)
+    }
+
+    files := map[string]string{}
+    sig := []string{}
+    for _, entry := range entries {
+        if entry.IsDir() || filepath.Ext(entry.Name()) != e.extension {
+            continue
+        }
+        info, err := entry.Info()
+        if err != nil {
+            continue
+        }
+        files[strings.TrimSuffix(entry.Name(), e.extension)] = filepath.Join(e.partialsDir, entry.Name())
+        sig = append(sig, entry.Name(), info.ModTime().String(), strconv.FormatInt(info.Size(), 10))
+    }
+    sort.Strings(sig)
+    signature := strings.Join(sig, "\n")
+
+    e.mutex.RLock()
+    same := signature == e.partialsSig
+    e.mutex.RUnlock()
+    if same {
+        return nil
+    }
+
+    partials := map[string]string{}
+    for name, path := range files {
+        content, err := os.ReadFile(path)
+        if err != nil {
+            return fmt.Errorf("failed to read partial %s: %w", name, err)
+        }
+        partials[name] = string(content)
+    }
+
+    e.mutex.Lock()
+    defer e.mutex.Unlock()
+    if signature != e.partialsSig {
+        e.partials = partials
+        e.partialsSig = signature
+        e.generation++
+    }
+    return nil
+}
+
+// Partials return the source of every partial, for what render a template
+// without the engine (the pdf).
+func (e *DynamicEngine) Partials() (map[string]string, error) {
+    if err := e.refreshPartials(); err != nil {
+        return nil, err
+    }
+
+    e.mutex.RLock()
+    defer e.mutex.RUnlock()
+    partials := make(map[string]string, len(e.partials))
+    for name, source := range e.partials {
+        partials[name] = source
+    }
+    return partials, nil
+}
+
+// PartialsSignature change whenever a partial is added, removed or edited.
+func (e *DynamicEngine) PartialsSignature() string {
+    e.refreshPartials()
+
+    e.mutex.RLock()
+    defer e.mutex.RUnlock()
+    return e.partialsSig
+}
+
+// NOTE: The layout is a partial or another template, the template is defined
+//       on the set by its name so the layout can `{{ template "name" . }}` it.
+func (e *DynamicEngine) renderWithLayout(out io.Writer, tmpl *template.Template, templateName string, binding interface{}, layoutName string) error {
+    if tmpl.Lookup(layoutName) != nil {
+        return tmpl.ExecuteTemplate(out, layoutName, binding)
     }
 
     layout, err := e.getTemplate(layoutName)
@@ -155,19 +319,23 @@ func (e *DynamicEngine) renderWithLayout(out io.Writer, templateName string, bin
     return combined.ExecuteTemplate(out, layoutName, binding)
 }
 
-// ClearCache clears the template cache (useful for admin operations)
-func (e *DynamicEngine) ClearCache() {
-    e.mutex.Lock()
-    defer e.mutex.Unlock()
-    e.cache = make(map[string]*template.Template)
-    e.cacheTime = make(map[string]time.Time)
-}
-
 // ReloadTemplate forces reload of a specific template
 func (e *DynamicEngine) ReloadTemplate(name string) error {
     e.mutex.Lock()
     defer e.mutex.Unlock()
     delete(e.cache, name)
-    delete(e.cacheTime, name)
     return nil
 }
+
+func (e *DynamicEngine) Stats() EngineStats {
+    e.mutex.RLock()
+    defer e.mutex.RUnlock()
+    return EngineStats{
+        Hits:     e.hits.Load(),
+        Misses:   e.misses.Load(),
+        Reloads:  e.reloads.Load(),
+        Errors:   e.errors.Load(),
+        Cached:   len(e.cache),
+        Partials: len(e.partials),
+    }
+}
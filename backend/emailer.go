@@ -5,21 +5,165 @@ package main
 //   https://www.mailjerry.com/create-gmail-app-password
 
 import (
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "io"
     "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
     gomail "gopkg.in/mail.v2"
 )
 
-func sendEmailTo(backend *Backend, to string, subject string, body string) bool {
+type MailAttachment struct {
+    Filename    string
+    ContentType string
+    Data        []byte
+}
+
+// NOTE: Text is always sent, HTML is added as an alternative part when not empty.
+//       Sensitive is only for the outbox, see table.EmailOutbox.
+type Mail struct {
+    To          []string
+    Subject     string
+    Text        string
+    HTML        string
+    Attachments []MailAttachment
+    Sensitive   bool
+}
+
+type Mailer interface {
+    Send(mail *Mail) error
+}
+
+type MailTLSMode string
+
+const (
+    MailTLSStartTLS MailTLSMode = "starttls"
+    MailTLSImplicit MailTLSMode = "tls"
+    MailTLSNone     MailTLSMode = "none"
+)
+
+// NOTE: Driver is `smtp` or `file`, Dir is only used by the `file` driver.
+type MailConfig struct {
+    Driver   string      `yaml:"driver"`
+    From     string      `yaml:"from"`
+    Host     string      `yaml:"host"`
+    Port     int         `yaml:"port"`
+    TLSMode  MailTLSMode `yaml:"tls"`
+    Username string      `yaml:"username"`
+    Password string      `yaml:"password"`
+    Dir      string      `yaml:"dir"`
+}
+
+func newMailer(conf MailConfig) (Mailer, error) {
+    switch conf.Driver {
+    case "smtp":
+        switch conf.TLSMode {
+        case MailTLSStartTLS, MailTLSImplicit, MailTLSNone:
+        default:
+            return nil, fmt.Errorf("invalid smtp tls mode %q", conf.TLSMode)
+        }
+        if conf.Host == "" || conf.Port <= 0 {
+            return nil, errors.New("smtp host and port must be set")
+        }
+        return &SMTPMailer{conf: conf}, nil
+    case "file":
+        if err := os.MkdirAll(conf.Dir, 0755); err != nil {
+            return nil, fmt.Errorf("failed to create mail dir, %w", err)
+        }
+        return &FileMailer{dir: conf.Dir, from: conf.From}, nil
+    }
+    return nil, fmt.Errorf("unknown mail driver %q", conf.Driver)
+}
+
+func buildMessage(from string, mail *Mail) *gomail.Message {
     message := gomail.NewMessage()
 
-    message.SetHeader("From", backend.email)
-    message.SetHeader("To", to)
-    message.SetHeader("Subject", subject)
+    message.SetHeader("From", from)
+    message.SetHeader("To", mail.To...)
+    message.SetHeader("Subject", mail.Subject)
+    message.SetDateHeader("Date", time.Now())
+
+    message.SetBody("text/plain", mail.Text)
+    if mail.HTML != "" {
+        message.AddAlternative("text/html", mail.HTML)
+    }
+
+    for _, att := range mail.Attachments {
+        data := att.Data
+        settings := []gomail.FileSetting{
+            gomail.SetCopyFunc(func(w io.Writer) error {
+                _, err := w.Write(data)
+                return err
+            }),
+        }
+        if att.ContentType != "" {
+            settings = append(settings, gomail.SetHeader(map[string][]string{
+                "Content-Type": {att.ContentType},
+            }))
+        }
+        message.Attach(att.Filename, settings...)
+    }
+    return message
+}
+
+type SMTPMailer struct {
+    conf MailConfig
+}
+
+func (m *SMTPMailer) Send(mail *Mail) error {
+    dialer := gomail.NewDialer(m.conf.Host, m.conf.Port, m.conf.Username, m.conf.Password)
+    switch m.conf.TLSMode {
+    case MailTLSImplicit:
+        dialer.SSL = true
+    case MailTLSNone:
+        dialer.StartTLSPolicy = gomail.NoStartTLS
+    default:
+        dialer.StartTLSPolicy = gomail.MandatoryStartTLS
+    }
+    dialer.TLSConfig = &tls.Config{ServerName: m.conf.Host}
+
+    return dialer.DialAndSend(buildMessage(m.conf.From, mail))
+}
+
+// NOTE: Write every mail as an .eml file so OTP and notification can be
+//       read without a real smtp server (open it with any mail client).
+type FileMailer struct {
+    dir  string
+    from string
+}
 
-    message.SetBody("text/plain", body)
-    dialer := gomail.NewDialer("smtp.gmail.com", 587, backend.email, backend.emailpass)
+func (m *FileMailer) Send(mail *Mail) error {
+    name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.ReplaceAll(strings.Join(mail.To, "_"), "/", "_"))
+    tmpPath := filepath.Join(m.dir, "."+name+".tmp")
 
-    if err := dialer.DialAndSend(message); err != nil {
+    file, err := os.Create(tmpPath)
+    if err != nil {
+        return err
+    }
+
+    _, err = buildMessage(m.from, mail).WriteTo(file)
+    if cerr := file.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        os.Remove(tmpPath)
+        return err
+    }
+    return os.Rename(tmpPath, filepath.Join(m.dir, name))
+}
+
+func sendEmailTo(backend *Backend, to string, subject string, body string) bool {
+    err := backend.mailer.Send(&Mail{
+        To:      []string{to},
+        Subject: subject,
+        Text:    body,
+    })
+    if err != nil {
         log.Println("Error:", err)
         return false
     }
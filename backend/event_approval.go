@@ -0,0 +1,152 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "webrpl/table"
+
+    "gorm.io/gorm"
+)
+
+// NOTE: In the order given, while there is a seat they are registered and the
+//       rest go to the back of the waitlist. A rejected one can still be
+//       approved if the committee change its mind. The code is made again
+//       here, whatever was on the row before was never given to the user.
+//       Return the one that was approved (the other was handled first by
+//       someone else).
+func approveParticipants(backend *Backend, eventId int, parts []table.EventParticipant) ([]table.EventParticipant, error) {
+    var event *table.Event
+    var approved []table.EventParticipant
+    err := backend.db.Transaction(func(tx *gorm.DB) error {
+        var err error
+        event, err = lockEvent(tx, eventId)
+        if err != nil {
+            return err
+        }
+
+        registered, err := countRegistered(tx, eventId)
+        if err != nil {
+            return err
+        }
+
+        for _, part := range parts {
+            part.EventPStatus = table.RegisteredP
+            part.EventPWaitPos = nil
+            part.EventPCode = newParticipantCode()
+            if int(registered) >= event.EventMax {
+                pos, err := nextWaitPos(tx, eventId)
+                if err != nil {
+                    return err
+                }
+                part.EventPStatus = table.WaitlistedP
+                part.EventPWaitPos = &pos
+            }
+
+            res := tx.Model(&table.EventParticipant{}).
+                Where("id = ? AND eventp_status IN ?", part.ID, []table.ParticipantStatusEnum{table.PendingP, table.RejectedP}).
+                Updates(map[string]any{
+                    "eventp_status":   part.EventPStatus,
+                    "eventp_wait_pos": part.EventPWaitPos,
+                    "eventp_code":     part.EventPCode,
+                })
+            if res.Error != nil {
+                return res.Error
+            }
+            if res.RowsAffected == 0 {
+                continue
+            }
+            if part.EventPStatus == table.RegisteredP {
+                registered++
+            }
+            approved = append(approved, part)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range approved {
+        notifyApproval(backend, &approved[i], event, "")
+    }
+    return approved, nil
+}
+
+// Only a pending registration can be rejected, an approved one is removed
+// with event-participate-del instead. Return the one that was rejected.
+func rejectParticipants(backend *Backend, event *table.Event, parts []table.EventParticipant, reason string) ([]table.EventParticipant, error) {
+    var rejected []table.EventParticipant
+    err := backend.db.Transaction(func(tx *gorm.DB) error {
+        for _, part := range parts {
+            res := tx.Model(&table.EventParticipant{}).
+                Where("id = ? AND eventp_status = ?", part.ID, table.PendingP).
+                Update("eventp_status", table.RejectedP)
+            if res.Error != nil {
+                return res.Error
+            }
+            if res.RowsAffected == 0 {
+                continue
+            }
+            part.EventPStatus = table.RejectedP
+            rejected = append(rejected, part)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range rejected {
+        notifyApproval(backend, &rejected[i], event, reason)
+    }
+    return rejected, nil
+}
+
+// NOTE: Queued after the commit like notifyPromoted, the mail depend on the
+//       new status of the participant. Reason is only for a rejection.
+func notifyApproval(backend *Backend, part *table.EventParticipant, event *table.Event, reason string) {
+    if part.User.ID == 0 {
+        backend.db.Where("id = ?", part.UserId).First(&part.User)
+    }
+    if part.User.UserEmail == "" {
+        return
+    }
+
+    var subject, body string
+    switch part.EventPStatus {
+    case table.RegisteredP:
+        subject = fmt.Sprintf("Approved : %s", event.EventName)
+        body = fmt.Sprintf(
+            "Hi %s,\n\nYour registration for \"%s\" is approved.\nIt start at %s.\nLink : %s\n",
+            part.User.UserFullName,
+            event.EventName,
+            event.EventDStart.Format("02 Jan 2006 15:04 MST"),
+            event.EventLink,
+        )
+    case table.WaitlistedP:
+        subject = fmt.Sprintf("Approved : %s", event.EventName)
+        body = fmt.Sprintf(
+            "Hi %s,\n\nYour registration for \"%s\" is approved, but the event is full so you are number %d on the waitlist.\nYou will get another mail when a seat open up.\n",
+            part.User.UserFullName,
+            event.EventName,
+            *part.EventPWaitPos,
+        )
+    case table.RejectedP:
+        subject = fmt.Sprintf("Registration declined : %s", event.EventName)
+        body = fmt.Sprintf(
+            "Hi %s,\n\nYour registration for \"%s\" was not approved by the committee.\n",
+            part.User.UserFullName,
+            event.EventName,
+        )
+        if reason != "" {
+            body += fmt.Sprintf("Reason : %s\n", reason)
+        }
+    default:
+        return
+    }
+
+    err := queueEmailTo(backend, part.User.UserEmail, subject, body)
+    if err != nil {
+        log.Printf("WARN: Failed to queue the approval mail of participant %d, %v", part.ID, err)
+    }
+}
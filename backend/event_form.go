@@ -0,0 +1,201 @@
+package main
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+    "webrpl/table"
+)
+
+const (
+    eventFormMaxFields   = 30
+    eventFormMaxOptions  = 50
+    eventFormMaxText     = 500
+    eventFormMaxTextarea = 4000
+)
+
+// NOTE: The name is the key of the answer and a column on the export, keep
+//       it something that dont need quoting.
+var eventFormNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// One problem of a form definition or of an answer, Field is the name of the
+// field (empty when it is about the whole form).
+type eventFormError struct {
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+func (e eventFormError) Error() string {
+    if e.Field == "" {
+        return e.Message
+    }
+    return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Check the form of an event before it is saved, an empty form is fine (no
+// question on registration).
+func validateEventForm(form []table.EventFormField) []eventFormError {
+    if len(form) > eventFormMaxFields {
+        return []eventFormError{{Message: fmt.Sprintf("a form can have at most %d fields", eventFormMaxFields)}}
+    }
+
+    errs := []eventFormError{}
+    seen := map[string]bool{}
+    for _, field := range form {
+        if !eventFormNameRe.MatchString(field.Name) {
+            errs = append(errs, eventFormError{Field: field.Name, Message: "name must be lowercase letters, digits or _ and start with a letter"})
+            continue
+        }
+        if seen[field.Name] {
+            errs = append(errs, eventFormError{Field: field.Name, Message: "name is used twice"})
+            continue
+        }
+        seen[field.Name] = true
+
+        if strings.TrimSpace(field.Label) == "" {
+            errs = append(errs, eventFormError{Field: field.Name, Message: "label is required"})
+        }
+
+        switch field.Type {
+        case table.FormText, table.FormTextarea, table.FormNumber, table.FormCheckbox:
+            if len(field.Options) > 0 {
+                errs = append(errs, eventFormError{Field: field.Name, Message: fmt.Sprintf("options are only for %s", table.FormSelect)})
+            }
+        case table.FormSelect:
+            if len(field.Options) == 0 || len(field.Options) > eventFormMaxOptions {
+                errs = append(errs, eventFormError{Field: field.Name, Message: fmt.Sprintf("select need 1 to %d options", eventFormMaxOptions)})
+                continue
+            }
+            options := map[string]bool{}
+            for _, option := range field.Options {
+                if strings.TrimSpace(option) == "" || options[option] {
+                    errs = append(errs, eventFormError{Field: field.Name, Message: "options must be unique and not empty"})
+                    break
+                }
+                options[option] = true
+            }
+        default:
+            errs = append(errs, eventFormError{Field: field.Name, Message: fmt.Sprintf("unknown type %q", field.Type)})
+        }
+    }
+    return errs
+}
+
+// Check the answers of a registration against the form of the event and
+// return them as string, the way they are saved. An answer to a field that
+// is not on the form is an error. requireAll is false for the committee
+// adding someone, they may not know every answer.
+func validateFormAnswers(form []table.EventFormField, answers map[string]any, requireAll bool) (map[string]string, []eventFormError) {
+    errs := []eventFormError{}
+    known := map[string]bool{}
+    for _, field := range form {
+        known[field.Name] = true
+    }
+    for name := range answers {
+        if !known[name] {
+            errs = append(errs, eventFormError{Field: name, Message: "not a field of this form"})
+        }
+    }
+
+    result := map[string]string{}
+    for _, field := range form {
+        value, ok := answers[field.Name]
+        if s, isString := value.(string); isString {
+            value = strings.TrimSpace(s)
+            ok = ok && value != ""
+        }
+        if !ok || value == nil {
+            if field.Required && requireAll {
+                errs = append(errs, eventFormError{Field: field.Name, Message: "is required"})
+            }
+            continue
+        }
+
+        answer, msg := formAnswerString(field, value)
+        if msg != "" {
+            errs = append(errs, eventFormError{Field: field.Name, Message: msg})
+            continue
+        }
+        // NOTE: An unchecked checkbox is still saved, it is an answer.
+        if field.Type == table.FormCheckbox && answer != "true" && field.Required && requireAll {
+            errs = append(errs, eventFormError{Field: field.Name, Message: "must be checked"})
+            continue
+        }
+        result[field.Name] = answer
+    }
+
+    if len(errs) > 0 {
+        return nil, errs
+    }
+    return result, nil
+}
+
+// The answer as it is saved, or the message of what is wrong with it.
+func formAnswerString(field table.EventFormField, value any) (string, string) {
+    switch field.Type {
+    case table.FormText, table.FormTextarea:
+        s, ok := value.(string)
+        if !ok {
+            return "", "must be a string"
+        }
+        limit := eventFormMaxText
+        if field.Type == table.FormTextarea {
+            limit = eventFormMaxTextarea
+        }
+        if utf8.RuneCountInString(s) > limit {
+            return "", fmt.Sprintf("must be at most %d characters", limit)
+        }
+        if field.Type == table.FormText && strings.ContainsAny(s, "\r\n") {
+            return "", "must be a single line"
+        }
+        return s, ""
+    case table.FormNumber:
+        switch v := value.(type) {
+        case float64:
+            return strconv.FormatFloat(v, 'f', -1, 64), ""
+        case string:
+            if n, err := strconv.ParseFloat(v, 64); err == nil {
+                return strconv.FormatFloat(n, 'f', -1, 64), ""
+            }
+        }
+        return "", "must be a number"
+    case table.FormSelect:
+        s, ok := value.(string)
+        if ok {
+            for _, option := range field.Options {
+                if s == option {
+                    return s, ""
+                }
+            }
+        }
+        return "", fmt.Sprintf("must be one of %s", strings.Join(field.Options, ", "))
+    case table.FormCheckbox:
+        b, ok := value.(bool)
+        if !ok {
+            return "", "must be true or false"
+        }
+        return strconv.FormatBool(b), ""
+    }
+    return "", fmt.Sprintf("unknown type %q", field.Type)
+}
+
+// NOTE: The export column of a field is its name, the label can be anything.
+func formAnswerHeader(form []table.EventFormField) []string {
+    header := make([]string, len(form))
+    for i, field := range form {
+        header[i] = field.Name
+    }
+    return header
+}
+
+// The answers in the order of formAnswerHeader, empty when not answered (or
+// the field was added after the registration).
+func formAnswerRow(form []table.EventFormField, answers map[string]string) []string {
+    row := make([]string, len(form))
+    for i, field := range form {
+        row[i] = answers[field.Name]
+    }
+    return row
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "log"
+    "time"
+    "webrpl/table"
+
+    "gorm.io/gorm"
+)
+
+var (
+    errAlreadyRegistered = errors.New("user already registered on this event")
+    errEventFull         = errors.New("event is already full")
+    errRegNotOpen        = errors.New("registration is not open yet")
+    errRegClosed         = errors.New("registration is closed")
+    errCancelClosed      = errors.New("cancellation deadline has passed")
+    errRegRejected       = errors.New("registration was rejected")
+)
+
+// NOTE: A time on an edit body that can be cleared, `null` unset it and a
+//       missing key leave it as is.
+type optionalTime struct {
+    Set  bool
+    Time *time.Time
+}
+
+func (t *optionalTime) UnmarshalJSON(data []byte) error {
+    t.Set = true
+    if bytes.Equal(data, []byte("null")) {
+        t.Time = nil
+        return nil
+    }
+    var v time.Time
+    if err := json.Unmarshal(data, &v); err != nil {
+        return err
+    }
+    t.Time = &v
+    return nil
+}
+
+// The window must open before it close, and the cancellation can not be
+// allowed after the event end.
+func validRegWindow(event *table.Event) bool {
+    if event.EventRegOpen != nil && event.EventRegClose != nil && !event.EventRegOpen.Before(*event.EventRegClose) {
+        return false
+    }
+    if event.EventCancelUntil != nil && event.EventCancelUntil.After(event.EventDEnd) {
+        return false
+    }
+    return true
+}
+
+// errRegNotOpen, errRegClosed or nil.
+func checkRegWindow(event *table.Event, now time.Time) error {
+    if event.EventRegOpen != nil && now.Before(*event.EventRegOpen) {
+        return errRegNotOpen
+    }
+    if event.EventRegClose != nil && !now.Before(*event.EventRegClose) {
+        return errRegClosed
+    }
+    return nil
+}
+
+// NOTE: Without a deadline it is until the event start, after that the
+//       attendance is being taken.
+func checkCancelDeadline(event *table.Event, now time.Time) error {
+    deadline := event.EventDStart
+    if event.EventCancelUntil != nil {
+        deadline = *event.EventCancelUntil
+    }
+    if !now.Before(deadline) {
+        return errCancelClosed
+    }
+    return nil
+}
+
+// NOTE: A no-op update instead of `FOR UPDATE`, sqlite ignore the locking
+//       clause but this take its write lock too. Every registration (and
+//       promotion) of the event wait here until the one before commit, so
+//       the count read after it is still true on the insert.
+func lockEvent(tx *gorm.DB, eventId int) (*table.Event, error) {
+    res := tx.Exec("UPDATE events SET id = id WHERE id = ?", eventId)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+
+    var event table.Event
+    if err := tx.Where("id = ?", eventId).First(&event).Error; err != nil {
+        return nil, err
+    }
+    return &event, nil
+}
+
+// Register the user on the event, or on its waitlist when the event is full
+// (a committee is never waitlisted, errEventFull). A participant that was
+// removed before get the same row back since (event_id, user_id) is unique,
+// soft deleted or not. The answers are already checked against the form.
+// A pending registration wait for the committee and dont take a seat yet.
+func registerParticipant(backend *Backend, eventId int, userId int, role table.UserEventRoleEnum, answers map[string]string, pending bool) (*table.EventParticipant, error) {
+    var part table.EventParticipant
+    err := backend.db.Transaction(func(tx *gorm.DB) error {
+        event, err := lockEvent(tx, eventId)
+        if err != nil {
+            return err
+        }
+
+        var old table.EventParticipant
+        res := tx.Unscoped().Where("event_id = ? AND user_id = ?", eventId, userId).Limit(1).Find(&old)
+        if res.Error != nil {
+            return res.Error
+        }
+        if res.RowsAffected > 0 && !old.DeletedAt.Valid {
+            if old.EventPStatus == table.RejectedP {
+                return errRegRejected
+            }
+            return errAlreadyRegistered
+        }
+
+        registered, err := countRegistered(tx, eventId)
+        if err != nil {
+            return err
+        }
+
+        // NOTE: It used to be full one seat early (`EventMax <= count + 1`).
+        part = table.EventParticipant{
+            EventId:      eventId,
+            UserId:       userId,
+            EventPRole:   role,
+            EventPCome:   role == table.CommitteeU,
+            EventPCode:   newParticipantCode(),
+            EventPStatus: table.RegisteredP,
+            EventPAnswers: answers,
+        }
+        if pending {
+            part.EventPStatus = table.PendingP
+        } else if int(registered) >= event.EventMax {
+            if role == table.CommitteeU {
+                return errEventFull
+            }
+            pos, err := nextWaitPos(tx, eventId)
+            if err != nil {
+                return err
+            }
+            part.EventPStatus = table.WaitlistedP
+            part.EventPWaitPos = &pos
+        }
+
+        if res.RowsAffected == 0 {
+            return tx.Create(&part).Error
+        }
+
+        // NOTE: Everything of the old registration is reset, the certificate
+        //       it had stay with the row but it need to come again for it.
+        part.ID = old.ID
+        part.CreatedAt = old.CreatedAt
+        // NOTE: A map on Updates skip the serializer of the field.
+        answersJson, err := json.Marshal(part.EventPAnswers)
+        if err != nil {
+            return err
+        }
+        return tx.Unscoped().Model(&table.EventParticipant{}).
+            Where("id = ?", old.ID).
+            Updates(map[string]any{
+                "deleted_at":          nil,
+                "eventp_role":         part.EventPRole,
+                "eventp_come":         part.EventPCome,
+                "eventp_code":         part.EventPCode,
+                "eventp_status":       part.EventPStatus,
+                "eventp_wait_pos":     part.EventPWaitPos,
+                "eventp_reminded_at":  nil,
+                "eventp_cert_sent_at": nil,
+                "eventp_answers":      string(answersJson),
+            }).
+            Error
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &part, nil
+}
+
+// Soft delete the participant and give the seat to the waitlist (or close the
+// gap on it). The delete is what matter, a failed waitlist update is only
+// logged. Return how many was promoted.
+func removeParticipant(backend *Backend, part *table.EventParticipant) (int, error) {
+    res := backend.db.Delete(&table.EventParticipant{}, part.ID)
+    if res.Error != nil {
+        return 0, res.Error
+    }
+
+    promoted := 0
+    var err error
+    switch part.EventPStatus {
+    case table.WaitlistedP:
+        err = compactWaitlist(backend.db, part.EventId)
+    case table.RegisteredP:
+        promoted, err = promoteWaitlist(backend, part.EventId)
+    }
+    if err != nil {
+        log.Printf("WARN: Failed to update the waitlist of event %d, %v", part.EventId, err)
+    }
+    return promoted, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+    "webrpl/table"
+)
+
+// NOTE: A file db with a few connection, `:memory:` only have one so every
+//       registration would wait on the pool instead of lockEvent.
+func TestRegisterParticipantConcurrent(t *testing.T) {
+    const (
+        max     = 5
+        users   = 40
+        threads = 12
+    )
+
+    db := openTestDB(t, filepath.Join(t.TempDir(), "race.db")+"?_busy_timeout=10000")
+    sqlDB, _ := db.DB()
+    sqlDB.SetMaxOpenConns(threads)
+    backend := &Backend{db: db}
+
+    event := table.Event{EventName: "Race", EventMax: max, EventDStart: time.Now(), EventDEnd: time.Now()}
+    if err := db.Create(&event).Error; err != nil {
+        t.Fatalf("create event: %v", err)
+    }
+    userIds := make([]int, users+1)
+    for i := range userIds {
+        user := table.User{UserEmail: fmt.Sprintf("race-%d@example.com", i)}
+        if err := db.Create(&user).Error; err != nil {
+            t.Fatalf("create user: %v", err)
+        }
+        userIds[i] = user.ID
+    }
+    twice := userIds[users]
+    userIds = userIds[:users]
+
+    // Every goroutine wait on start so they all hit registerParticipant at once.
+    run := func(ids []int) []error {
+        errs := make([]error, len(ids))
+        start := make(chan struct{})
+        var wg sync.WaitGroup
+        sem := make(chan struct{}, threads)
+        for i, id := range ids {
+            wg.Add(1)
+            go func() {
+                defer wg.Done()
+                <-start
+                sem <- struct{}{}
+                defer func() { <-sem }()
+                _, errs[i] = registerParticipant(backend, event.ID, id, table.NormalU, nil, false)
+            }()
+        }
+        close(start)
+        wg.Wait()
+        return errs
+    }
+
+    for i, err := range run(userIds) {
+        if err != nil {
+            t.Errorf("user %d: %v", userIds[i], err)
+        }
+    }
+
+    sameUser := make([]int, threads)
+    for i := range sameUser {
+        sameUser[i] = twice
+    }
+    ok := 0
+    for _, err := range run(sameUser) {
+        switch {
+        case err == nil:
+            ok++
+        case !errors.Is(err, errAlreadyRegistered):
+            t.Errorf("same user: %v", err)
+        }
+    }
+    if ok != 1 {
+        t.Errorf("same user registered %d times at once, want 1", ok)
+    }
+
+    registered, err := countRegistered(db, event.ID)
+    if err != nil {
+        t.Fatalf("countRegistered: %v", err)
+    }
+    if registered > max {
+        t.Fatalf("%d registered on an event of %d", registered, max)
+    }
+    if registered != max {
+        t.Errorf("%d registered, want the event full at %d", registered, max)
+    }
+
+    var rows []struct {
+        UserId int
+        Count  int
+    }
+    err = db.Unscoped().Model(&table.EventParticipant{}).
+        Select("user_id, COUNT(*) AS count").
+        Where("event_id = ?", event.ID).
+        Group("user_id").
+        Scan(&rows).
+        Error
+    if err != nil {
+        t.Fatalf("count rows per user: %v", err)
+    }
+    if len(rows) != users+1 {
+        t.Errorf("%d user have a row, want %d", len(rows), users+1)
+    }
+    for _, row := range rows {
+        if row.Count != 1 {
+            t.Errorf("user %d have %d rows, want 1", row.UserId, row.Count)
+        }
+    }
+
+    var positions []int
+    err = db.Model(&table.EventParticipant{}).
+        Where("event_id = ? AND eventp_status = ?", event.ID, table.WaitlistedP).
+        Order("eventp_wait_pos").
+        Pluck("eventp_wait_pos", &positions).
+        Error
+    if err != nil {
+        t.Fatalf("read waitlist: %v", err)
+    }
+    if len(positions) != users+1-max {
+        t.Errorf("%d waitlisted, want %d", len(positions), users+1-max)
+    }
+    for i, pos := range positions {
+        if pos != i+1 {
+            t.Fatalf("waitlist positions = %v, want 1..%d", positions, len(positions))
+        }
+    }
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "webrpl/table"
+
+    "gorm.io/gorm"
+)
+
+// Only the registered participant take a seat, the waitlisted one dont.
+func countRegistered(db *gorm.DB, eventId int) (int64, error) {
+    var count int64
+    res := db.Model(&table.EventParticipant{}).
+        Where("event_id = ? AND eventp_status = ?", eventId, table.RegisteredP).
+        Count(&count)
+    return count, res.Error
+}
+
+func nextWaitPos(db *gorm.DB, eventId int) (int, error) {
+    var last int
+    res := db.Model(&table.EventParticipant{}).
+        Where("event_id = ? AND eventp_status = ?", eventId, table.WaitlistedP).
+        Select("COALESCE(MAX(eventp_wait_pos), 0)").
+        Scan(&last)
+    return last + 1, res.Error
+}
+
+// NOTE: Renumber the waitlist to 1..n after someone leave it, so the stored
+//       position is also the one shown to the user.
+func compactWaitlist(db *gorm.DB, eventId int) error {
+    var waiting []table.EventParticipant
+    res := db.Select("id", "eventp_wait_pos").
+        Where("event_id = ? AND eventp_status = ?", eventId, table.WaitlistedP).
+        Order("eventp_wait_pos ASC, id ASC").
+        Find(&waiting)
+    if res.Error != nil {
+        return res.Error
+    }
+    for i, part := range waiting {
+        pos := i + 1
+        if part.EventPWaitPos != nil && *part.EventPWaitPos == pos {
+            continue
+        }
+        err := db.Model(&table.EventParticipant{}).
+            Where("id = ?", part.ID).
+            Update("eventp_wait_pos", pos).
+            Error
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Move one waitlisted participant to registered, false when it was not
+// waitlisted anymore (promoted by someone else first).
+func promoteParticipant(db *gorm.DB, part *table.EventParticipant) (bool, error) {
+    res := db.Model(&table.EventParticipant{}).
+        Where("id = ? AND eventp_status = ?", part.ID, table.WaitlistedP).
+        Updates(map[string]any{
+            "eventp_status":   table.RegisteredP,
+            "eventp_wait_pos": nil,
+        })
+    if res.Error != nil {
+        return false, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return false, nil
+    }
+    part.EventPStatus = table.RegisteredP
+    part.EventPWaitPos = nil
+    return true, nil
+}
+
+// NOTE: Queued after the promotion is committed, a failed mail dont undo it.
+func notifyPromoted(backend *Backend, part *table.EventParticipant, event *table.Event) {
+    if part.User.ID == 0 {
+        backend.db.Where("id = ?", part.UserId).First(&part.User)
+    }
+    if part.User.UserEmail == "" {
+        return
+    }
+
+    body := fmt.Sprintf(
+        "Hi %s,\n\nA seat opened up and you are now registered for \"%s\".\nIt start at %s.\nLink : %s\n",
+        part.User.UserFullName,
+        event.EventName,
+        event.EventDStart.Format("02 Jan 2006 15:04 MST"),
+        event.EventLink,
+    )
+    err := queueEmailTo(backend, part.User.UserEmail, fmt.Sprintf("Registered : %s", event.EventName), body)
+    if err != nil {
+        log.Printf("WARN: Failed to queue the waitlist mail of participant %d, %v", part.ID, err)
+    }
+}
+
+// NOTE: Fill every free seat from the front of the waitlist, called after a
+//       participant is removed or EventMax is raised. Same lock as the
+//       registration so a seat is never given twice. Return how many was
+//       promoted.
+func promoteWaitlist(backend *Backend, eventId int) (int, error) {
+    var event *table.Event
+    var promoted []table.EventParticipant
+    err := backend.db.Transaction(func(tx *gorm.DB) error {
+        var err error
+        event, err = lockEvent(tx, eventId)
+        if err != nil {
+            return err
+        }
+
+        registered, err := countRegistered(tx, eventId)
+        if err != nil {
+            return err
+        }
+        free := event.EventMax - int(registered)
+        if free <= 0 {
+            return nil
+        }
+
+        var waiting []table.EventParticipant
+        res := tx.Preload("User").
+            Where("event_id = ? AND eventp_status = ?", eventId, table.WaitlistedP).
+            Order("eventp_wait_pos ASC, id ASC").
+            Limit(free).
+            Find(&waiting)
+        if res.Error != nil {
+            return res.Error
+        }
+        for i := range waiting {
+            ok, err := promoteParticipant(tx, &waiting[i])
+            if err != nil {
+                return err
+            }
+            if ok {
+                promoted = append(promoted, waiting[i])
+            }
+        }
+        if len(promoted) == 0 {
+            return nil
+        }
+        return compactWaitlist(tx, eventId)
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    for i := range promoted {
+        notifyPromoted(backend, &promoted[i], event)
+    }
+    return len(promoted), nil
+}
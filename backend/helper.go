@@ -1,16 +1,18 @@
 package main
 
 import (
+    "crypto/hmac"
     "crypto/rand"
-    "encoding/base64"
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/hex"
     "errors"
     "fmt"
     "math/big"
     "net/mail"
-    "os"
+    "strings"
     "time"
     "webrpl/table"
-    "log"
 
     "github.com/gofiber/fiber/v2"
     "github.com/golang-jwt/jwt/v5"
@@ -23,6 +25,11 @@ func isEmailValid(e string) bool {
     return err == nil
 }
 
+// NOTE: path is relative to the public base url, eg. `static/1.png`.
+func publicURL(backend *Backend, path string) string {
+    return backend.config.PublicBaseURL + "/" + strings.TrimLeft(path, "/")
+}
+
 func checkOrMakeAdmin(backend *Backend, secret string) bool {
     reserved := "admin@wowadmin.com"
     var user table.User
@@ -39,6 +46,13 @@ func checkOrMakeAdmin(backend *Backend, secret string) bool {
                 return false
             }
         }
+        // NOTE: The reserved admin was role 1 before super-admin exist.
+        if user.UserRole != table.RoleSuperAdmin {
+            res := backend.db.Model(&user).Update("user_role", table.RoleSuperAdmin)
+            if res.Error != nil {
+                return false
+            }
+        }
         return true
     }
 
@@ -55,7 +69,7 @@ func checkOrMakeAdmin(backend *Backend, secret string) bool {
         UserEmail:    reserved,
         UserFullName: "admin",
         UserPassword: hashed,
-        UserRole:     1,
+        UserRole:     table.RoleSuperAdmin,
     }
 
     if err := backend.db.Create(&user).Error; err != nil {
@@ -65,21 +79,6 @@ func checkOrMakeAdmin(backend *Backend, secret string) bool {
     return true
 }
 
-func getCredentialFromEnv() SecretHolder {
-    password := os.Getenv("WRPL_SECRET")
-    email := os.Getenv("WRPL_EMAIL")
-    emailAppPass := os.Getenv("WRPL_EMAPPPASS")
-    if password == "" {
-        password = "secret"
-    }
-    sec := SecretHolder{
-        Password: password,
-        Email: email,
-        EmailAppPassword: emailAppPass,
-    }
-    return sec
-}
-
 func HashPassword(password string) (string, error) {
     // The cost parameter determines how computationally expensive the hash is to calculate
     // The default is 10, but you can increase it for better security (at the cost of performance)
@@ -95,9 +94,11 @@ func CheckPassword(hashedPassword, plainPassword string) bool {
     return err == nil
 }
 
-func RandStringBytes(backend *Backend, value string) string {
-    str := base64.StdEncoding.EncodeToString([]byte(value))
-    return str
+// NOTE: The participant code is the attendance secret and the certificate
+//       link, so it must not be guessable. 26 char of base32 (130 bit) from
+//       crypto/rand, only A-Z and 2-7 so it is safe on url and qr.
+func newParticipantCode() string {
+    return rand.Text()
 }
 
 func GetJWT(c *fiber.Ctx) (jwt.MapClaims, error) {
@@ -112,66 +113,122 @@ func GetJWT(c *fiber.Ctx) (jwt.MapClaims, error) {
     return claims, nil
 }
 
-const otpExpiryDuration = 5 * time.Minute
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
-func createOTPCode(backend *Backend, n int, userEmail string) (*table.OTP, error) {
+
+var (
+    errOTPInvalid = errors.New("otp code is wrong or does not exist")
+    errOTPExpired = errors.New("otp code is expired or already used")
+    errOTPLocked  = errors.New("otp code is locked after too many wrong attempt")
+)
+
+// NOTE: hmac with the jwt secret so a leaked db alone is not enough to brute
+//       force the short code. The purpose and email are part of it so the hash
+//       cant be moved to another row.
+func hashOTP(backend *Backend, purpose table.OTPPurposeEnum, email string, code string) string {
+    mac := hmac.New(sha256.New, []byte(backend.pass))
+    mac.Write([]byte(string(purpose) + "\n" + strings.ToLower(email) + "\n" + code))
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Make a new code for the email and purpose, the old one stop working. Only
+// the hash is saved, so the plain code is returned separately.
+func createOTPCode(backend *Backend, purpose table.OTPPurposeEnum, userEmail string) (string, *table.OTP, error) {
+    n := backend.config.OTP.Length
     if n <= 0 {
-        return nil, errors.New("invalid OTP len requested.")
+        return "", nil, errors.New("invalid OTP len requested.")
     }
 
     b := make([]byte, n)
     for i := range b {
         num, err := rand.Int(rand.Reader, big.NewInt(int64(len(letterBytes))))
         if err != nil {
-            return nil, err
+            return "", nil, err
         }
         b[i] = letterBytes[num.Int64()]
     }
     result := string(b)
 
-    var existingOTP table.OTP
-    res := backend.db.Where("user_email = ?", userEmail).First(&existingOTP)
-
-    if res.Error != nil {
-        if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
-            return nil, res.Error
+    newOTP := table.OTP{
+        UserEmail:   userEmail,
+        OtpPurpose:  purpose,
+        OtpCode:     hashOTP(backend, purpose, userEmail, result),
+        TimeCreated: time.Now(),
+        Used:        false,
+    }
+    err := backend.db.Transaction(func(tx *gorm.DB) error {
+        res := tx.Where("user_email = ? AND otp_purpose = ?", userEmail, purpose).Delete(&table.OTP{})
+        if res.Error != nil {
+            return res.Error
         }
+        return tx.Create(&newOTP).Error
+    })
+    if err != nil {
+        return "", nil, fmt.Errorf("failed to create new OTP, %w", err)
+    }
+    return result, &newOTP, nil
+}
 
-        newOTP := table.OTP{
-            UserEmail:   userEmail,
-            OtpCode:     result,
-            TimeCreated: time.Now(),
-            Used:        false,
-        }
-        if err := backend.db.Create(&newOTP).Error; err != nil {
-            return nil, errors.New("failed to create new OTP")
+func IsOTPExpired(backend *Backend, otp *table.OTP) bool {
+    return time.Since(otp.TimeCreated) > backend.config.OTP.TTL
+}
+
+// NOTE: The otp is looked up by the email only, so a wrong code can be counted.
+//       The error is one of errOTP* or a db error. It is not consumed here,
+//       use consumeOTP on the same transaction as whatever the otp is for.
+func verifyOTP(backend *Backend, c *fiber.Ctx, purpose table.OTPPurposeEnum, email string, code string) (*table.OTP, error) {
+    var otp table.OTP
+    res := backend.db.Where("user_email = ? AND otp_purpose = ?", email, purpose).
+        Order("time_created DESC").
+        First(&otp)
+    if res.Error != nil {
+        if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+            return nil, errOTPInvalid
         }
-        return &newOTP, nil
+        return nil, res.Error
     }
 
-    if time.Since(existingOTP.TimeCreated) < otpExpiryDuration {
-        return &existingOTP, nil
+    if otp.OtpLocked {
+        return nil, errOTPLocked
     }
 
-    existingOTP.OtpCode = result
-    existingOTP.TimeCreated = time.Now()
-
-    if err := backend.db.Save(&existingOTP).Error; err != nil {
-        return nil, errors.New("failed to update existing OTP")
+    hashed := hashOTP(backend, purpose, email, code)
+    if subtle.ConstantTimeCompare([]byte(otp.OtpCode), []byte(hashed)) != 1 {
+        locked, err := recordOTPFailure(backend, c, &otp)
+        if err != nil {
+            return nil, err
+        }
+        if locked {
+            return nil, errOTPLocked
+        }
+        return nil, errOTPInvalid
     }
-    return &existingOTP, nil
-}
 
-func IsOTPExpired(otp *table.OTP) bool {
-	return time.Since(otp.TimeCreated) > otpExpiryDuration
+    if IsOTPExpired(backend, &otp) || otp.Used {
+        return nil, errOTPExpired
+    }
+    return &otp, nil
 }
 
-func CleanupOTPTable(backend *Backend) {
-    expiryCutoff := time.Now().Add(-otpExpiryDuration)
-    res := backend.db.Where("created_at < ?", expiryCutoff).Delete(&table.OTP{})
+// Mark the otp as used, conditional on it still being unused so two request
+// with the same code only one of them get through.
+func consumeOTP(tx *gorm.DB, otp *table.OTP) error {
+    res := tx.Model(&table.OTP{}).
+        Where("id = ? AND used = ?", otp.ID, false).
+        Update("used", true)
     if res.Error != nil {
-        log.Printf("Failed to cleanup OTPs: %v", res.Error)
-    } else {
-        log.Printf("Cleaned up %d expired OTP entries", res.RowsAffected)
+        return res.Error
+    }
+    if res.RowsAffected == 0 {
+        return errOTPExpired
     }
+    otp.Used = true
+    return nil
+}
+
+// NOTE: time_created and not created_at, the row used to be reused. Unscoped
+//       so the row is really gone and not only soft deleted.
+func CleanupOTPTable(backend *Backend) (int64, error) {
+    expiryCutoff := time.Now().Add(-backend.config.OTP.TTL)
+    res := backend.db.Unscoped().Where("time_created < ? OR used = ?", expiryCutoff, true).Delete(&table.OTP{})
+    return res.RowsAffected, res.Error
 }
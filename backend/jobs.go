@@ -0,0 +1,161 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "net/url"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "time"
+    "webrpl/table"
+)
+
+const reminderBatchSize = 200
+
+func jobOTPCleanup(backend *Backend) (string, error) {
+    count, err := CleanupOTPTable(backend)
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("deleted %d otp", count), nil
+}
+
+// NOTE: Expired and revoked session are kept for a while so the user can
+//       still see them, session_keep is how long.
+func jobSessionCleanup(backend *Backend) (string, error) {
+    cutoff := time.Now().Add(-backend.config.Jobs.SessionKeep)
+    res := backend.db.Unscoped().
+        Where("session_expire < ? OR session_revoked_at < ?", cutoff, cutoff).
+        Delete(&table.Session{})
+    if res.Error != nil {
+        return "", res.Error
+    }
+    return fmt.Sprintf("deleted %d session", res.RowsAffected), nil
+}
+
+// Every upload name still referenced on the db, only the base name since
+// some column have the full url and some only the path.
+func usedUploadNames(backend *Backend) (map[string]bool, error) {
+    columns := []struct {
+        model  any
+        column string
+    }{
+        {&table.User{}, "user_picture"},
+        {&table.Event{}, "event_img"},
+        {&table.EventMaterial{}, "eventm_attach"},
+        {&table.CertTemplate{}, "cert_template"},
+    }
+
+    used := map[string]bool{}
+    for _, col := range columns {
+        var values []string
+        if err := backend.db.Model(col.model).Where(col.column + " <> ''").Pluck(col.column, &values).Error; err != nil {
+            return nil, err
+        }
+        for _, value := range values {
+            if u, err := url.Parse(value); err == nil && u.Path != "" {
+                value = u.Path
+            }
+            if unescaped, err := url.PathUnescape(value); err == nil {
+                value = unescaped
+            }
+            used[path.Base(strings.TrimRight(value, "/"))] = true
+        }
+    }
+    return used, nil
+}
+
+// NOTE: Only the file right on the static dir, the folder on it are the
+//       certificate editor one. Anything newer than orphan_grace is skipped
+//       since the image is uploaded before the form that use it is saved.
+func jobOrphanCleanup(backend *Backend) (string, error) {
+    dir := backend.config.Upload.StaticDir
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return "no upload dir", nil
+        }
+        return "", err
+    }
+
+    used, err := usedUploadNames(backend)
+    if err != nil {
+        return "", err
+    }
+
+    cutoff := time.Now().Add(-backend.config.Jobs.OrphanGrace)
+    count := 0
+    for _, entry := range entries {
+        name := entry.Name()
+        if entry.IsDir() || strings.HasPrefix(name, ".") || used[name] {
+            continue
+        }
+        info, err := entry.Info()
+        if err != nil || info.ModTime().After(cutoff) {
+            continue
+        }
+        if err := os.Remove(filepath.Join(dir, name)); err != nil {
+            log.Printf("WARN: Failed to remove orphan upload %s, %v", name, err)
+            continue
+        }
+        count++
+    }
+    return fmt.Sprintf("deleted %d file", count), nil
+}
+
+// NOTE: eventp_reminded_at is claimed before the mail is queued, so two
+//       instance running this at once dont send it twice.
+func jobEventReminder(backend *Backend) (string, error) {
+    now := time.Now()
+    events := backend.db.Model(&table.Event{}).
+        Select("id").
+        Where("event_dstart > ? AND event_dstart <= ?", now, now.Add(backend.config.Jobs.ReminderBefore))
+
+    var parts []table.EventParticipant
+    res := backend.db.Preload("Event").Preload("User").
+        Where("eventp_reminded_at IS NULL AND eventp_status = ? AND event_id IN (?)", table.RegisteredP, events).
+        Limit(reminderBatchSize).
+        Find(&parts)
+    if res.Error != nil {
+        return "", res.Error
+    }
+
+    count := 0
+    for _, part := range parts {
+        claim := backend.db.Model(&table.EventParticipant{}).
+            Where("id = ? AND eventp_reminded_at IS NULL", part.ID).
+            Update("eventp_reminded_at", now)
+        if claim.Error != nil || claim.RowsAffected == 0 || part.User.ID == 0 {
+            continue
+        }
+
+        body := fmt.Sprintf(
+            "Hi %s,\n\nThis is a reminder that \"%s\" start at %s.\nLink : %s\n",
+            part.User.UserFullName,
+            part.Event.EventName,
+            part.Event.EventDStart.Format("02 Jan 2006 15:04 MST"),
+            part.Event.EventLink,
+        )
+        err := queueEmailTo(backend, part.User.UserEmail, fmt.Sprintf("Reminder : %s", part.Event.EventName), body)
+        if err != nil {
+            log.Printf("WARN: Failed to queue the reminder of participant %d, %v", part.ID, err)
+            backend.db.Model(&table.EventParticipant{}).
+                Where("id = ?", part.ID).
+                Update("eventp_reminded_at", nil)
+            continue
+        }
+        count++
+    }
+    return fmt.Sprintf("queued %d reminder", count), nil
+}
+
+// NOTE: Attendance confirmed before the event end get its certificate here.
+func jobCertificateIssue(backend *Backend) (string, error) {
+    count, err := issueCertificates(backend, 0)
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("issued %d certificate", count), nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+    "log"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+func recordLockout(backend *Backend, c *fiber.Ctx, kind table.LockoutKindEnum, email string, attempts int, until *time.Time) {
+    lockout := table.Lockout{
+        LockKind:     kind,
+        LockEmail:    email,
+        LockIP:       c.IP(),
+        LockAttempts: attempts,
+        LockUntil:    until,
+    }
+    if err := backend.db.Create(&lockout).Error; err != nil {
+        log.Printf("WARN: Failed to record the %s lockout of %s, %v", kind, email, err)
+        return
+    }
+    log.Printf("WARN: %s of %s locked after %d failed attempt from %s", kind, email, attempts, c.IP())
+}
+
+// Return how long until the account is unlocked, 0 when it is not locked.
+func userLockedFor(user *table.User) time.Duration {
+    if user.UserLockedUntil == nil {
+        return 0
+    }
+    return max(time.Until(*user.UserLockedUntil), 0)
+}
+
+// NOTE: The counter is bumped on the db and not on the struct, so a burst of
+//       request at the same time still count every one of them. Only the
+//       request that win the second update write the lockout row.
+func recordLoginFailure(backend *Backend, c *fiber.Ctx, user *table.User) (bool, error) {
+    maxFailures := backend.config.Security.MaxLoginFailures
+    if maxFailures <= 0 {
+        return false, nil
+    }
+
+    res := backend.db.Model(&table.User{}).
+        Where("id = ?", user.ID).
+        Update("user_failed_login", gorm.Expr("user_failed_login + 1"))
+    if res.Error != nil {
+        return false, res.Error
+    }
+
+    until := time.Now().Add(backend.config.Security.LockoutDuration)
+    res = backend.db.Model(&table.User{}).
+        Where("id = ? AND user_failed_login >= ?", user.ID, maxFailures).
+        Updates(map[string]any{
+            "user_failed_login": 0,
+            "user_locked_until": until,
+        })
+    if res.Error != nil {
+        return false, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return false, nil
+    }
+
+    recordLockout(backend, c, table.LockoutLogin, user.UserEmail, maxFailures, &until)
+    return true, nil
+}
+
+func clearLoginFailure(backend *Backend, user *table.User) error {
+    if user.UserFailedLogin == 0 && user.UserLockedUntil == nil {
+        return nil
+    }
+    return backend.db.Model(&table.User{}).
+        Where("id = ?", user.ID).
+        Updates(map[string]any{
+            "user_failed_login": 0,
+            "user_locked_until": nil,
+        }).Error
+}
+
+// Same idea as recordLoginFailure, but the otp stay locked until a new code is made.
+func recordOTPFailure(backend *Backend, c *fiber.Ctx, otp *table.OTP) (bool, error) {
+    maxFailures := backend.config.Security.MaxOTPFailures
+    res := backend.db.Model(&table.OTP{}).
+        Where("id = ?", otp.ID).
+        Update("otp_attempts", gorm.Expr("otp_attempts + 1"))
+    if res.Error != nil || maxFailures <= 0 {
+        return false, res.Error
+    }
+
+    res = backend.db.Model(&table.OTP{}).
+        Where("id = ? AND otp_locked = ? AND otp_attempts >= ?", otp.ID, false, maxFailures).
+        Update("otp_locked", true)
+    if res.Error != nil {
+        return false, res.Error
+    }
+    if res.RowsAffected == 0 {
+        return false, nil
+    }
+
+    recordLockout(backend, c, table.LockoutOTP, otp.UserEmail, maxFailures, nil)
+    return true, nil
+}
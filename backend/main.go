@@ -1,61 +1,62 @@
 package main
 
 import (
-	"fmt"
 	l "log"
 	"os"
-	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
 func main() {
-    ip := "0.0.0.0"
-    port := 3000
-
-    ip_env   := os.Getenv("WRPL_IP")
-    port_env := os.Getenv("WRPL_PORT")
-    if len(ip_env) > 0 {
-        ip = ip_env
-    }
-    if len(port_env) > 0 {
-        convert, err := strconv.Atoi(port_env)
-        if err == nil {
-            port = convert
-        }
+    config, err := loadConfig()
+    if err != nil {
+        l.Fatal("ERR: Invalid configuration:\n", err)
+        return
     }
 
-    add := fmt.Sprintf("%s:%d", ip, port)
-
     // DO THE DB STUFF
-    db, err := open_db("./db/data.db")
+    db, err := open_db(config.DB)
     if err != nil {
-        l.Fatal("ERR: Failed to open the db.")
+        l.Fatal("ERR: Failed to open the db: ", err)
         return
     }
+
+    if len(os.Args) > 1 && os.Args[1] == "migrate" {
+        if err := run_migrate_cli(db, os.Args[2:]); err != nil {
+            l.Fatal("ERR: ", err)
+        }
+        return
+    }
+
     err = migrate_db(db)
     if err != nil {
         l.Fatal("ERR: Failed to mirgrate the db.")
         return
     }
     l.Println("INFO: DB init task completed successfully.")
-    sec := getCredentialFromEnv()
-    password := sec.Password
 
-    app := appCreateNewServer(db, sec, add)
+    mailer, err := newMailer(config.Mail)
+    if err != nil {
+        l.Fatal("ERR: Failed to setup the mailer: ", err)
+        return
+    }
+
+    app := appCreateNewServer(db, config, mailer)
     app.app.Use(cors.New(cors.Config{
-        AllowOrigins: "*",
+        AllowOrigins: strings.Join(config.CORS.AllowOrigins, ", "),
         AllowHeaders: "Origin, Content-Type, Accept, Authorization",
         AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
         AllowCredentials: false,
     }))
 
-    if !checkOrMakeAdmin(app, password) {
+    if !checkOrMakeAdmin(app, config.AdminPassword) {
         l.Panic("ERR: There is a problem when making user 0 (SUPER ADMIN)")
     }
     appMakeRouteHandler(app)
-    const hardcodeAddress = "0.0.0.0:3000"
-    if err := app.app.Listen(hardcodeAddress); err != nil {
+    startEmailWorker(app)
+    startScheduler(app)
+    if err := app.app.Listen(config.ListenAddress); err != nil {
         l.Fatal("ERR: Server failed to start: ", err)
     }
 }
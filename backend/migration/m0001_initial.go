@@ -0,0 +1,121 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// NOTE: The layout of `webrpl/table` at the time migration was introduced.
+//       It use AutoMigrate so db that was made by the old migrate_db is a no-op.
+//       The old `type:datetime` tag is dropped because postgres dont have it,
+//       gorm already pick datetime for time.Time on sqlite so nothing change there.
+
+type user0001 struct {
+    gorm.Model
+    ID             int       `gorm:"primaryKey"`
+    UserFullName   string    `gorm:"column:user_full_name"`
+    UserPassword   string    `gorm:"column:user_password"`
+    UserEmail      string    `gorm:"column:user_email"`
+    UserInstance   string    `gorm:"column:user_instance"`
+    UserRole       int       `gorm:"column:user_role"`
+    UserPicture    string    `gorm:"column:user_picture"`
+    UserCreatedAt  time.Time `gorm:"column:user_created_at"`
+
+    EventParticipants []eventParticipant0001 `gorm:"foreignKey:UserId"`
+}
+
+func (user0001) TableName() string { return "users" }
+
+type event0001 struct {
+    gorm.Model
+    ID           int       `gorm:"primaryKey"`
+    EventDesc    string    `gorm:"column:event_desc"`
+    EventName    string    `gorm:"column:event_name"`
+    EventImg     string    `gorm:"column:event_img"`
+    EventMax     int       `gorm:"column:event_max"`
+    EventDStart  time.Time `gorm:"column:event_dstart"`
+    EventDEnd    time.Time `gorm:"column:event_dend"`
+    EventLink    string    `gorm:"column:event_link"`
+    EventSpeaker string    `gorm:"column:event_speaker"`
+    EventAtt     string    `gorm:"column:event_att"`
+
+    EventMaterials    []eventMaterial0001    `gorm:"foreignKey:EventId"`
+    EventParticipants []eventParticipant0001 `gorm:"foreignKey:EventId"`
+    CertTemplates     []certTemplate0001     `gorm:"foreignKey:EventId"`
+}
+
+func (event0001) TableName() string { return "events" }
+
+type otp0001 struct {
+    gorm.Model
+    ID          int       `gorm:"primaryKey"`
+    UserEmail   string    `gorm:"column:user_email"`
+    OtpCode     string    `gorm:"column:otp_code"`
+    TimeCreated time.Time `gorm:"column:time_created"`
+    Used        bool      `gorm:"column:used"`
+}
+
+func (otp0001) TableName() string { return "otps" }
+
+type eventParticipant0001 struct {
+    gorm.Model
+    ID           int    `gorm:"primaryKey"`
+    EventId      int    `gorm:"column:event_id"`
+    UserId       int    `gorm:"column:user_id"`
+    EventPRole   string `gorm:"column:eventp_role"`
+    EventPCome   bool   `gorm:"column:eventp_come"`
+    EventPCode   string `gorm:"column:eventp_code"`
+
+    Event        event0001 `gorm:"foreignKey:EventId"`
+    User         user0001  `gorm:"foreignKey:UserId"`
+}
+
+func (eventParticipant0001) TableName() string { return "event_participants" }
+
+type eventMaterial0001 struct {
+    gorm.Model
+    ID                 int    `gorm:"primaryKey"`
+    EventId            int    `gorm:"column:event_id"`
+    EventMatAttachment string `gorm:"column:eventm_attach"`
+
+    Event              event0001 `gorm:"foreignKey:EventId"`
+}
+
+func (eventMaterial0001) TableName() string { return "event_materials" }
+
+type certTemplate0001 struct {
+    gorm.Model
+    ID           int    `gorm:"primaryKey"`
+    CertTemplate string `gorm:"column:cert_template"`
+    EventId      int    `gorm:"column:event_id"`
+
+    Event   event0001  `gorm:"foreignKey:EventId"`
+}
+
+func (certTemplate0001) TableName() string { return "cert_templates" }
+
+var m0001Initial = Migration{
+    Version: "0001",
+    Name:    "initial",
+    Up: func(tx *gorm.DB) error {
+        return tx.AutoMigrate(
+            &user0001{},
+            &event0001{},
+            &otp0001{},
+            &eventParticipant0001{},
+            &eventMaterial0001{},
+            &certTemplate0001{},
+        )
+    },
+    Down: func(tx *gorm.DB) error {
+        return tx.Migrator().DropTable(
+            &certTemplate0001{},
+            &eventMaterial0001{},
+            &eventParticipant0001{},
+            &otp0001{},
+            &event0001{},
+            &user0001{},
+        )
+    },
+}
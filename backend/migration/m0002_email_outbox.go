@@ -0,0 +1,35 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+type emailOutbox0002 struct {
+    gorm.Model
+    ID             int        `gorm:"primaryKey"`
+    EmailTo        string     `gorm:"column:email_to"`
+    EmailSubject   string     `gorm:"column:email_subject"`
+    EmailText      string     `gorm:"column:email_text"`
+    EmailHTML      string     `gorm:"column:email_html"`
+    EmailAttach    string     `gorm:"column:email_attach"`
+    EmailStatus    string     `gorm:"column:email_status;size:16;index"`
+    EmailAttempts  int        `gorm:"column:email_attempts"`
+    EmailLastError string     `gorm:"column:email_last_error"`
+    EmailNextTry   time.Time  `gorm:"column:email_next_try"`
+    EmailSentAt    *time.Time `gorm:"column:email_sent_at"`
+}
+
+func (emailOutbox0002) TableName() string { return "email_outboxes" }
+
+var m0002EmailOutbox = Migration{
+    Version: "0002",
+    Name:    "email_outbox",
+    Up: func(tx *gorm.DB) error {
+        return tx.AutoMigrate(&emailOutbox0002{})
+    },
+    Down: func(tx *gorm.DB) error {
+        return tx.Migrator().DropTable(&emailOutbox0002{})
+    },
+}
@@ -0,0 +1,35 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+type session0003 struct {
+    gorm.Model
+    ID               int        `gorm:"primaryKey"`
+    UserId           int        `gorm:"column:user_id;index"`
+    SessionKey       string     `gorm:"column:session_key;size:64;uniqueIndex"`
+    SessionRefresh   string     `gorm:"column:session_refresh;size:64;uniqueIndex"`
+    SessionAgent     string     `gorm:"column:session_agent"`
+    SessionIP        string     `gorm:"column:session_ip"`
+    SessionExpire    time.Time  `gorm:"column:session_expire"`
+    SessionLastUsed  time.Time  `gorm:"column:session_last_used"`
+    SessionRevokedAt *time.Time `gorm:"column:session_revoked_at"`
+
+    User             user0001   `gorm:"foreignKey:UserId"`
+}
+
+func (session0003) TableName() string { return "sessions" }
+
+var m0003Session = Migration{
+    Version: "0003",
+    Name:    "session",
+    Up: func(tx *gorm.DB) error {
+        return tx.AutoMigrate(&session0003{})
+    },
+    Down: func(tx *gorm.DB) error {
+        return tx.Migrator().DropTable(&session0003{})
+    },
+}
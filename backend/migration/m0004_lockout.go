@@ -0,0 +1,102 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// NOTE: Only the new column, the rest of the table is still the one from 0001.
+//       The default is needed so the old row dont get NULL on postgres.
+type userLock0004 struct {
+    UserFailedLogin int        `gorm:"column:user_failed_login;not null;default:0"`
+    UserLockedUntil *time.Time `gorm:"column:user_locked_until"`
+}
+
+func (userLock0004) TableName() string { return "users" }
+
+type otpLock0004 struct {
+    OtpAttempts int  `gorm:"column:otp_attempts;not null;default:0"`
+    OtpLocked   bool `gorm:"column:otp_locked;not null;default:false"`
+}
+
+func (otpLock0004) TableName() string { return "otps" }
+
+type lockout0004 struct {
+    gorm.Model
+    ID           int        `gorm:"primaryKey"`
+    LockKind     string     `gorm:"column:lock_kind;size:16;index"`
+    LockEmail    string     `gorm:"column:lock_email;size:255;index"`
+    LockIP       string     `gorm:"column:lock_ip"`
+    LockAttempts int        `gorm:"column:lock_attempts"`
+    LockUntil    *time.Time `gorm:"column:lock_until"`
+}
+
+func (lockout0004) TableName() string { return "lockouts" }
+
+func addColumns(tx *gorm.DB, model any, fields ...string) error {
+    for _, field := range fields {
+        if tx.Migrator().HasColumn(model, field) {
+            continue
+        }
+        if err := tx.Migrator().AddColumn(model, field); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// NOTE: Not Migrator().DropColumn, on sqlite it copy the table to a new one
+//       and every index of the table is lost on the way (the unique one too).
+//       A plain DROP COLUMN keep them, sqlite have it since 3.35. It refuse an
+//       indexed column so the index of the field is dropped first.
+func dropColumns(tx *gorm.DB, model any, fields ...string) error {
+    stmt := &gorm.Statement{DB: tx}
+    if err := stmt.Parse(model); err != nil {
+        return err
+    }
+    for _, field := range fields {
+        if !tx.Migrator().HasColumn(model, field) {
+            continue
+        }
+        if tx.Migrator().HasIndex(model, field) {
+            if err := tx.Migrator().DropIndex(model, field); err != nil {
+                return err
+            }
+        }
+
+        column := field
+        if f := stmt.Schema.LookUpField(field); f != nil {
+            column = f.DBName
+        }
+        err := tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Schema.Table}, clause.Column{Name: column}).Error
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+var m0004Lockout = Migration{
+    Version: "0004",
+    Name:    "lockout",
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &userLock0004{}, "UserFailedLogin", "UserLockedUntil"); err != nil {
+            return err
+        }
+        if err := addColumns(tx, &otpLock0004{}, "OtpAttempts", "OtpLocked"); err != nil {
+            return err
+        }
+        return tx.AutoMigrate(&lockout0004{})
+    },
+    Down: func(tx *gorm.DB) error {
+        if err := tx.Migrator().DropTable(&lockout0004{}); err != nil {
+            return err
+        }
+        if err := dropColumns(tx, &otpLock0004{}, "OtpAttempts", "OtpLocked"); err != nil {
+            return err
+        }
+        return dropColumns(tx, &userLock0004{}, "UserFailedLogin", "UserLockedUntil")
+    },
+}
@@ -0,0 +1,41 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type otpPurpose0005 struct {
+    OtpPurpose string `gorm:"column:otp_purpose;size:32;index"`
+}
+
+func (otpPurpose0005) TableName() string { return "otps" }
+
+// NOTE: The old row have the plain code and no purpose, they only live for a
+//       few minute anyway so they are just dropped instead of converted.
+var m0005OTPPurpose = Migration{
+    Version: "0005",
+    Name:    "otp_purpose",
+    Up: func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM otps").Error; err != nil {
+            return err
+        }
+        if err := addColumns(tx, &otpPurpose0005{}, "OtpPurpose"); err != nil {
+            return err
+        }
+        if tx.Migrator().HasIndex(&otpPurpose0005{}, "OtpPurpose") {
+            return nil
+        }
+        return tx.Migrator().CreateIndex(&otpPurpose0005{}, "OtpPurpose")
+    },
+    Down: func(tx *gorm.DB) error {
+        if tx.Migrator().HasIndex(&otpPurpose0005{}, "OtpPurpose") {
+            if err := tx.Migrator().DropIndex(&otpPurpose0005{}, "OtpPurpose"); err != nil {
+                return err
+            }
+        }
+        if err := tx.Exec("DELETE FROM otps").Error; err != nil {
+            return err
+        }
+        return dropColumns(tx, &otpPurpose0005{}, "OtpPurpose")
+    },
+}
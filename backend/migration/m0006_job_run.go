@@ -0,0 +1,45 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+type jobRun0006 struct {
+    gorm.Model
+    ID           int        `gorm:"primaryKey"`
+    JobName      string     `gorm:"column:job_name;size:64;uniqueIndex"`
+    JobStatus    string     `gorm:"column:job_status;size:16"`
+    JobMessage   string     `gorm:"column:job_message"`
+    JobManual    bool       `gorm:"column:job_manual"`
+    JobStartedAt *time.Time `gorm:"column:job_started_at"`
+    JobEndedAt   *time.Time `gorm:"column:job_ended_at"`
+    JobRunCount  int        `gorm:"column:job_run_count"`
+    JobFailCount int        `gorm:"column:job_fail_count"`
+}
+
+func (jobRun0006) TableName() string { return "job_runs" }
+
+type eventParticipantRemind0006 struct {
+    EventPRemindedAt *time.Time `gorm:"column:eventp_reminded_at"`
+}
+
+func (eventParticipantRemind0006) TableName() string { return "event_participants" }
+
+var m0006JobRun = Migration{
+    Version: "0006",
+    Name:    "job_run",
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &eventParticipantRemind0006{}, "EventPRemindedAt"); err != nil {
+            return err
+        }
+        return tx.AutoMigrate(&jobRun0006{})
+    },
+    Down: func(tx *gorm.DB) error {
+        if err := tx.Migrator().DropTable(&jobRun0006{}); err != nil {
+            return err
+        }
+        return dropColumns(tx, &eventParticipantRemind0006{}, "EventPRemindedAt")
+    },
+}
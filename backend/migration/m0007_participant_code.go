@@ -0,0 +1,84 @@
+package migration
+
+import (
+    "crypto/rand"
+
+    "gorm.io/gorm"
+)
+
+type eventParticipantCode0007 struct {
+    ID               int    `gorm:"primaryKey"`
+    EventPCome       bool   `gorm:"column:eventp_come"`
+    EventPCode       string `gorm:"column:eventp_code;size:64;uniqueIndex"`
+    EventPLegacyCode string `gorm:"column:eventp_legacy_code;size:255;index"`
+}
+
+func (eventParticipantCode0007) TableName() string { return "event_participants" }
+
+// NOTE: The old code is base64 of the email, so every row get a new one. The
+//       old code is only kept for the participant that came, that is the one
+//       with a certificate link out there, attendance only take the new one.
+//       Soft deleted row are included or they would block the unique index.
+var m0007ParticipantCode = Migration{
+    Version: "0007",
+    Name:    "participant_code",
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &eventParticipantCode0007{}, "EventPLegacyCode"); err != nil {
+            return err
+        }
+
+        var rows []eventParticipantCode0007
+        if err := tx.Find(&rows).Error; err != nil {
+            return err
+        }
+        for _, row := range rows {
+            legacy := ""
+            if row.EventPCome {
+                legacy = row.EventPCode
+            }
+            res := tx.Model(&eventParticipantCode0007{}).
+                Where("id = ?", row.ID).
+                Updates(map[string]any{
+                    "eventp_code":        rand.Text(),
+                    "eventp_legacy_code": legacy,
+                })
+            if res.Error != nil {
+                return res.Error
+            }
+        }
+
+        // NOTE: sqlite dont care about the size, the other need a varchar for the index.
+        if tx.Dialector.Name() != "sqlite" {
+            if err := tx.Migrator().AlterColumn(&eventParticipantCode0007{}, "EventPCode"); err != nil {
+                return err
+            }
+        }
+        if !tx.Migrator().HasIndex(&eventParticipantCode0007{}, "EventPCode") {
+            if err := tx.Migrator().CreateIndex(&eventParticipantCode0007{}, "EventPCode"); err != nil {
+                return err
+            }
+        }
+        if tx.Migrator().HasIndex(&eventParticipantCode0007{}, "EventPLegacyCode") {
+            return nil
+        }
+        return tx.Migrator().CreateIndex(&eventParticipantCode0007{}, "EventPLegacyCode")
+    },
+    // NOTE: Only the code that was kept come back, the rest keep the new one.
+    Down: func(tx *gorm.DB) error {
+        for _, field := range []string{"EventPCode", "EventPLegacyCode"} {
+            if !tx.Migrator().HasIndex(&eventParticipantCode0007{}, field) {
+                continue
+            }
+            if err := tx.Migrator().DropIndex(&eventParticipantCode0007{}, field); err != nil {
+                return err
+            }
+        }
+        res := tx.Model(&eventParticipantCode0007{}).
+            Where("eventp_legacy_code <> ?", "").
+            Update("eventp_code", gorm.Expr("eventp_legacy_code"))
+        if res.Error != nil {
+            return res.Error
+        }
+        return dropColumns(tx, &eventParticipantCode0007{}, "EventPLegacyCode")
+    },
+}
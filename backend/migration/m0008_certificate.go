@@ -0,0 +1,35 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// NOTE: No relation on the struct, so no foreign key, a certificate should
+//       outlive its participant row.
+type certificate0008 struct {
+    gorm.Model
+    ID                  int        `gorm:"primaryKey"`
+    CertParticipantId   int        `gorm:"column:cert_participant_id;uniqueIndex:idx_certificates_participant_revision"`
+    CertRevision        int        `gorm:"column:cert_revision;uniqueIndex:idx_certificates_participant_revision"`
+    CertTemplateId      *int       `gorm:"column:cert_template_id"`
+    CertTemplateVersion string     `gorm:"column:cert_template_version;size:64"`
+    CertSerial          string     `gorm:"column:cert_serial;size:32;uniqueIndex"`
+    CertIssuedAt        time.Time  `gorm:"column:cert_issued_at"`
+    CertRevokedAt       *time.Time `gorm:"column:cert_revoked_at"`
+    CertRevokeReason    string     `gorm:"column:cert_revoke_reason"`
+}
+
+func (certificate0008) TableName() string { return "certificates" }
+
+var m0008Certificate = Migration{
+    Version: "0008",
+    Name:    "certificate",
+    Up: func(tx *gorm.DB) error {
+        return tx.AutoMigrate(&certificate0008{})
+    },
+    Down: func(tx *gorm.DB) error {
+        return tx.Migrator().DropTable(&certificate0008{})
+    },
+}
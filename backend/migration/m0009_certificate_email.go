@@ -0,0 +1,24 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+type eventParticipantCertSent0009 struct {
+    EventPCertSentAt *time.Time `gorm:"column:eventp_cert_sent_at"`
+}
+
+func (eventParticipantCertSent0009) TableName() string { return "event_participants" }
+
+var m0009CertificateEmail = Migration{
+    Version: "0009",
+    Name:    "certificate_email",
+    Up: func(tx *gorm.DB) error {
+        return addColumns(tx, &eventParticipantCertSent0009{}, "EventPCertSentAt")
+    },
+    Down: func(tx *gorm.DB) error {
+        return dropColumns(tx, &eventParticipantCertSent0009{}, "EventPCertSentAt")
+    },
+}
@@ -0,0 +1,69 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type certTemplateActive0010 struct {
+    ID                int    `gorm:"primaryKey"`
+    CertTemplate      string `gorm:"column:cert_template"`
+    CertActiveVersion *int   `gorm:"column:cert_active_version"`
+}
+
+func (certTemplateActive0010) TableName() string { return "cert_templates" }
+
+type certTemplateVersion0010 struct {
+    gorm.Model
+    ID              int    `gorm:"primaryKey"`
+    CertVTemplateId int    `gorm:"column:certv_template_id;uniqueIndex:idx_cert_template_versions_number"`
+    CertVNumber     int    `gorm:"column:certv_number;uniqueIndex:idx_cert_template_versions_number"`
+    CertVPath       string `gorm:"column:certv_path"`
+    CertVHash       string `gorm:"column:certv_hash;size:64"`
+    CertVAuthorId   *int   `gorm:"column:certv_author_id"`
+}
+
+func (certTemplateVersion0010) TableName() string { return "cert_template_versions" }
+
+// NOTE: The template already there become version 1 with the file where they
+//       are, the editor save the next one on its own folder. The hash is left
+//       empty, the file are not reachable from here.
+var m0010CertTemplateVersion = Migration{
+    Version: "0010",
+    Name:    "cert_template_version",
+    Up: func(tx *gorm.DB) error {
+        if err := tx.AutoMigrate(&certTemplateVersion0010{}); err != nil {
+            return err
+        }
+        if err := addColumns(tx, &certTemplateActive0010{}, "CertActiveVersion"); err != nil {
+            return err
+        }
+
+        var temps []certTemplateActive0010
+        if err := tx.Where("cert_active_version IS NULL").Find(&temps).Error; err != nil {
+            return err
+        }
+        for _, temp := range temps {
+            version := certTemplateVersion0010{
+                CertVTemplateId: temp.ID,
+                CertVNumber:     1,
+                CertVPath:       temp.CertTemplate,
+            }
+            if err := tx.Create(&version).Error; err != nil {
+                return err
+            }
+            res := tx.Model(&certTemplateActive0010{}).
+                Where("id = ?", temp.ID).
+                Update("cert_active_version", version.ID)
+            if res.Error != nil {
+                return res.Error
+            }
+        }
+        return nil
+    },
+    Down: func(tx *gorm.DB) error {
+        if err := dropColumns(tx, &certTemplateActive0010{}, "CertActiveVersion"); err != nil {
+            return err
+        }
+        return tx.Migrator().DropTable(&certTemplateVersion0010{})
+    },
+}
@@ -0,0 +1,27 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type certLibrary0011 struct {
+    gorm.Model
+    ID            int    `gorm:"primaryKey"`
+    CertLName     string `gorm:"column:certl_name;size:100;uniqueIndex"`
+    CertLDesc     string `gorm:"column:certl_desc"`
+    CertLPath     string `gorm:"column:certl_path"`
+    CertLAuthorId *int   `gorm:"column:certl_author_id"`
+}
+
+func (certLibrary0011) TableName() string { return "cert_libraries" }
+
+var m0011CertLibrary = Migration{
+    Version: "0011",
+    Name:    "cert_library",
+    Up: func(tx *gorm.DB) error {
+        return tx.AutoMigrate(&certLibrary0011{})
+    },
+    Down: func(tx *gorm.DB) error {
+        return tx.Migrator().DropTable(&certLibrary0011{})
+    },
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type eventParticipantWaitlist0012 struct {
+    EventPStatus  string `gorm:"column:eventp_status;size:20;default:registered"`
+    EventPWaitPos *int   `gorm:"column:eventp_wait_pos"`
+}
+
+func (eventParticipantWaitlist0012) TableName() string { return "event_participants" }
+
+// NOTE: Everyone already there is registered, even when the event was over
+//       EventMax, nobody is moved to the waitlist. Going down drop the
+//       waitlisted one, they would be a participant over EventMax otherwise.
+var m0012Waitlist = Migration{
+    Version: "0012",
+    Name:    "waitlist",
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &eventParticipantWaitlist0012{}, "EventPStatus", "EventPWaitPos"); err != nil {
+            return err
+        }
+        return tx.Exec("UPDATE event_participants SET eventp_status = ? WHERE eventp_status IS NULL OR eventp_status = ''", "registered").Error
+    },
+    Down: func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM event_participants WHERE eventp_status = ?", "waitlisted").Error; err != nil {
+            return err
+        }
+        return dropColumns(tx, &eventParticipantWaitlist0012{}, "EventPStatus", "EventPWaitPos")
+    },
+}
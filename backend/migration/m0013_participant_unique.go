@@ -0,0 +1,149 @@
+package migration
+
+import (
+    "fmt"
+
+    "gorm.io/gorm"
+)
+
+type eventParticipantUnique0013 struct {
+    ID         int            `gorm:"primaryKey"`
+    EventId    int            `gorm:"column:event_id;uniqueIndex:idx_event_participants_event_user"`
+    UserId     int            `gorm:"column:user_id;uniqueIndex:idx_event_participants_event_user"`
+    EventPCome bool           `gorm:"column:eventp_come"`
+    DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at"`
+}
+
+func (eventParticipantUnique0013) TableName() string { return "event_participants" }
+
+type certificateUnique0013 struct {
+    ID                int `gorm:"primaryKey"`
+    CertParticipantId int `gorm:"column:cert_participant_id"`
+    CertRevision      int `gorm:"column:cert_revision"`
+}
+
+func (certificateUnique0013) TableName() string { return "certificates" }
+
+const participantUniqueIndex0013 = "idx_event_participants_event_user"
+
+// NOTE: A user registered twice (the race this index stop) keep one row, the
+//       one with a certificate first, then the one that came, then the live
+//       one, then the oldest. The kept row came (or is live) if any of them
+//       did. The certificate of a dropped row move to the kept row, unless
+//       both have the same revision, then it stop here since there is no
+//       right answer to pick. The other row are hard deleted, soft deleted
+//       included since the index cover them too.
+var m0013ParticipantUnique = Migration{
+    Version: "0013",
+    Name:    "participant_unique",
+    Up: func(tx *gorm.DB) error {
+        var rows []eventParticipantUnique0013
+        if err := tx.Unscoped().Order("event_id, user_id, id").Find(&rows).Error; err != nil {
+            return err
+        }
+
+        var certs []certificateUnique0013
+        if err := tx.Order("id").Find(&certs).Error; err != nil {
+            return err
+        }
+        certsOf := map[int][]certificateUnique0013{}
+        for _, cert := range certs {
+            certsOf[cert.CertParticipantId] = append(certsOf[cert.CertParticipantId], cert)
+        }
+
+        // Lower is better, the order is id so the oldest win a tie.
+        rank := func(row eventParticipantUnique0013) int {
+            switch {
+            case len(certsOf[row.ID]) > 0:
+                return 0
+            case row.EventPCome:
+                return 1
+            case !row.DeletedAt.Valid:
+                return 2
+            }
+            return 3
+        }
+
+        type key struct{ event, user int }
+        groups := map[key][]eventParticipantUnique0013{}
+        order := []key{}
+        for _, row := range rows {
+            k := key{row.EventId, row.UserId}
+            if _, ok := groups[k]; !ok {
+                order = append(order, k)
+            }
+            groups[k] = append(groups[k], row)
+        }
+
+        for _, k := range order {
+            group := groups[k]
+            if len(group) < 2 {
+                continue
+            }
+
+            kept := group[0]
+            for _, row := range group[1:] {
+                if rank(row) < rank(kept) {
+                    kept = row
+                }
+            }
+
+            revisions := map[int]bool{}
+            for _, cert := range certsOf[kept.ID] {
+                revisions[cert.CertRevision] = true
+            }
+            came, live := false, false
+            drop := []int{}
+            for _, row := range group {
+                came = came || row.EventPCome
+                live = live || !row.DeletedAt.Valid
+                if row.ID == kept.ID {
+                    continue
+                }
+                for _, cert := range certsOf[row.ID] {
+                    if revisions[cert.CertRevision] {
+                        return fmt.Errorf(
+                            "participant %d and %d (event %d, user %d) both have a certificate revision %d, merge them by hand before this migration",
+                            kept.ID, row.ID, k.event, k.user, cert.CertRevision,
+                        )
+                    }
+                    revisions[cert.CertRevision] = true
+                    err := tx.Model(&certificateUnique0013{}).
+                        Where("id = ?", cert.ID).
+                        Update("cert_participant_id", kept.ID).
+                        Error
+                    if err != nil {
+                        return err
+                    }
+                }
+                drop = append(drop, row.ID)
+            }
+
+            updates := map[string]any{"eventp_come": came}
+            if live {
+                updates["deleted_at"] = nil
+            }
+            err := tx.Unscoped().Model(&eventParticipantUnique0013{}).
+                Where("id = ?", kept.ID).
+                Updates(updates).
+                Error
+            if err != nil {
+                return err
+            }
+            if err := tx.Unscoped().Delete(&eventParticipantUnique0013{}, drop).Error; err != nil {
+                return err
+            }
+        }
+
+        if tx.Migrator().HasIndex(&eventParticipantUnique0013{}, participantUniqueIndex0013) {
+            return nil
+        }
+        return tx.Migrator().CreateIndex(&eventParticipantUnique0013{}, participantUniqueIndex0013)
+    },
+    Down: func(tx *gorm.DB) error {
+        if !tx.Migrator().HasIndex(&eventParticipantUnique0013{}, participantUniqueIndex0013) {
+            return nil
+        }
+        return tx.Migrator().DropIndex(&eventParticipantUnique0013{}, participantUniqueIndex0013)
+    },
+}
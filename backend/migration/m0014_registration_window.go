@@ -0,0 +1,26 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+type eventRegistrationWindow0014 struct {
+    EventRegOpen     *time.Time `gorm:"column:event_reg_open"`
+    EventRegClose    *time.Time `gorm:"column:event_reg_close"`
+    EventCancelUntil *time.Time `gorm:"column:event_cancel_until"`
+}
+
+func (eventRegistrationWindow0014) TableName() string { return "events" }
+
+var m0014RegistrationWindow = Migration{
+    Version: "0014",
+    Name:    "registration_window",
+    Up: func(tx *gorm.DB) error {
+        return addColumns(tx, &eventRegistrationWindow0014{}, "EventRegOpen", "EventRegClose", "EventCancelUntil")
+    },
+    Down: func(tx *gorm.DB) error {
+        return dropColumns(tx, &eventRegistrationWindow0014{}, "EventRegOpen", "EventRegClose", "EventCancelUntil")
+    },
+}
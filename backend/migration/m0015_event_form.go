@@ -0,0 +1,34 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type eventForm0015 struct {
+    EventForm string `gorm:"column:event_form;type:text"`
+}
+
+func (eventForm0015) TableName() string { return "events" }
+
+type eventParticipantAnswers0015 struct {
+    EventPAnswers string `gorm:"column:eventp_answers;type:text"`
+}
+
+func (eventParticipantAnswers0015) TableName() string { return "event_participants" }
+
+var m0015EventForm = Migration{
+    Version: "0015",
+    Name:    "event_form",
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &eventForm0015{}, "EventForm"); err != nil {
+            return err
+        }
+        return addColumns(tx, &eventParticipantAnswers0015{}, "EventPAnswers")
+    },
+    Down: func(tx *gorm.DB) error {
+        if err := dropColumns(tx, &eventParticipantAnswers0015{}, "EventPAnswers"); err != nil {
+            return err
+        }
+        return dropColumns(tx, &eventForm0015{}, "EventForm")
+    },
+}
@@ -0,0 +1,27 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type eventApproval0016 struct {
+    EventNeedApproval bool `gorm:"column:event_need_approval;default:false"`
+}
+
+func (eventApproval0016) TableName() string { return "events" }
+
+var m0016EventApproval = Migration{
+    Version: "0016",
+    Name:    "event_approval",
+    Up: func(tx *gorm.DB) error {
+        return addColumns(tx, &eventApproval0016{}, "EventNeedApproval")
+    },
+    // NOTE: Without the flag a pending or rejected participant mean nothing,
+    //       they are removed like the waitlist on 0012.
+    Down: func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM event_participants WHERE eventp_status IN ?", []string{"pending", "rejected"}).Error; err != nil {
+            return err
+        }
+        return dropColumns(tx, &eventApproval0016{}, "EventNeedApproval")
+    },
+}
@@ -0,0 +1,34 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type emailSensitive0017 struct {
+    EmailSensitive bool `gorm:"column:email_sensitive;default:false"`
+}
+
+func (emailSensitive0017) TableName() string { return "email_outboxes" }
+
+var m0017EmailSensitive = Migration{
+    Version: "0017",
+    Name:    "email_sensitive",
+    // NOTE: The otp mail queued before this still have the code on it, they
+    //       are flagged and the one that is done is blanked right away.
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &emailSensitive0017{}, "EmailSensitive"); err != nil {
+            return err
+        }
+        err := tx.Exec("UPDATE email_outboxes SET email_sensitive = ? WHERE email_subject = ?", true, "OTP code for webrpl").Error
+        if err != nil {
+            return err
+        }
+        return tx.Exec(
+            "UPDATE email_outboxes SET email_text = '', email_html = '' WHERE email_sensitive = ? AND email_status IN ?",
+            true, []string{"sent", "failed"},
+        ).Error
+    },
+    Down: func(tx *gorm.DB) error {
+        return dropColumns(tx, &emailSensitive0017{}, "EmailSensitive")
+    },
+}
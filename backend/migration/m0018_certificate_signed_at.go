@@ -0,0 +1,34 @@
+package migration
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+type certificateSignedAt0018 struct {
+    CertSignedAt time.Time `gorm:"column:cert_signed_at"`
+}
+
+func (certificateSignedAt0018) TableName() string { return "certificates" }
+
+var m0018CertificateSignedAt = Migration{
+    Version: "0018",
+    Name:    "certificate_signed_at",
+    // NOTE: The code already printed was signed with the event end, it is
+    //       copied here so they stay valid. A certificate without participant
+    //       anymore get its own issue date.
+    Up: func(tx *gorm.DB) error {
+        if err := addColumns(tx, &certificateSignedAt0018{}, "CertSignedAt"); err != nil {
+            return err
+        }
+        return tx.Exec(`UPDATE certificates SET cert_signed_at = COALESCE((
+            SELECT events.event_dend FROM event_participants
+            JOIN events ON events.id = event_participants.event_id
+            WHERE event_participants.id = certificates.cert_participant_id
+        ), cert_issued_at)`).Error
+    },
+    Down: func(tx *gorm.DB) error {
+        return dropColumns(tx, &certificateSignedAt0018{}, "CertSignedAt")
+    },
+}
@@ -0,0 +1,54 @@
+package migration
+
+import (
+    "gorm.io/gorm"
+)
+
+type certificateTemplateVersion0019 struct {
+    CertTemplateVersion   string `gorm:"column:cert_template_version;size:64"`
+    CertTemplateVersionId *int   `gorm:"column:cert_template_version_id;index"`
+}
+
+func (certificateTemplateVersion0019) TableName() string { return "certificates" }
+
+// NOTE: The hash of the certificate was the one the editor save on the
+//       version, so the row is found with it. A certificate of a template
+//       from before the version (or set by hand) have no match and stay nil.
+var m0019CertificateTemplateVersion = Migration{
+    Version: "0019",
+    Name:    "certificate_template_version",
+    Up: func(tx *gorm.DB) error {
+        model := &certificateTemplateVersion0019{}
+        if err := addColumns(tx, model, "CertTemplateVersionId"); err != nil {
+            return err
+        }
+        if !tx.Migrator().HasIndex(model, "CertTemplateVersionId") {
+            if err := tx.Migrator().CreateIndex(model, "CertTemplateVersionId"); err != nil {
+                return err
+            }
+        }
+        err := tx.Exec(`UPDATE certificates SET cert_template_version_id = (
+            SELECT MIN(cert_template_versions.id) FROM cert_template_versions
+            WHERE cert_template_versions.certv_template_id = certificates.cert_template_id
+            AND cert_template_versions.certv_hash = certificates.cert_template_version
+        ) WHERE cert_template_version <> ''`).Error
+        if err != nil {
+            return err
+        }
+        return dropColumns(tx, model, "CertTemplateVersion")
+    },
+    Down: func(tx *gorm.DB) error {
+        model := &certificateTemplateVersion0019{}
+        if err := addColumns(tx, model, "CertTemplateVersion"); err != nil {
+            return err
+        }
+        err := tx.Exec(`UPDATE certificates SET cert_template_version = COALESCE((
+            SELECT cert_template_versions.certv_hash FROM cert_template_versions
+            WHERE cert_template_versions.id = certificates.cert_template_version_id
+        ), '')`).Error
+        if err != nil {
+            return err
+        }
+        return dropColumns(tx, model, "CertTemplateVersionId")
+    },
+}
@@ -0,0 +1,165 @@
+package migration
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// NOTE: Every step must only touch its own frozen copy of the struct, never
+//       the one in `webrpl/table`, or the old step will change when the model does.
+type Migration struct {
+    Version string
+    Name    string
+    Up      func(tx *gorm.DB) error
+    Down    func(tx *gorm.DB) error
+}
+
+type SchemaMigration struct {
+    Version   string    `gorm:"primaryKey;column:version;size:32"`
+    Name      string    `gorm:"column:name;size:128"`
+    AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+    return "schema_migrations"
+}
+
+type Status struct {
+    Version   string
+    Name      string
+    Applied   bool
+    AppliedAt *time.Time
+}
+
+// Keep this sorted, new migration go to the bottom.
+var migrations = []Migration{
+    m0001Initial,
+    m0002EmailOutbox,
+    m0003Session,
+    m0004Lockout,
+    m0005OTPPurpose,
+    m0006JobRun,
+    m0007ParticipantCode,
+    m0008Certificate,
+    m0009CertificateEmail,
+    m0010CertTemplateVersion,
+    m0011CertLibrary,
+    m0012Waitlist,
+    m0013ParticipantUnique,
+    m0014RegistrationWindow,
+    m0015EventForm,
+    m0016EventApproval,
+    m0017EmailSensitive,
+    m0018CertificateSignedAt,
+    m0019CertificateTemplateVersion,
+}
+
+func ensureTable(db *gorm.DB) error {
+    return db.AutoMigrate(&SchemaMigration{})
+}
+
+func appliedVersions(db *gorm.DB) (map[string]SchemaMigration, error) {
+    var rows []SchemaMigration
+    if err := db.Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    applied := make(map[string]SchemaMigration, len(rows))
+    for _, row := range rows {
+        applied[row.Version] = row
+    }
+    return applied, nil
+}
+
+func List(db *gorm.DB) ([]Status, error) {
+    if err := ensureTable(db); err != nil {
+        return nil, err
+    }
+    applied, err := appliedVersions(db)
+    if err != nil {
+        return nil, err
+    }
+
+    result := make([]Status, 0, len(migrations))
+    for _, m := range migrations {
+        status := Status{Version: m.Version, Name: m.Name}
+        if row, ok := applied[m.Version]; ok {
+            appliedAt := row.AppliedAt
+            status.Applied = true
+            status.AppliedAt = &appliedAt
+        }
+        result = append(result, status)
+    }
+    return result, nil
+}
+
+// Up apply every pending migration in order, each one on its own transaction.
+func Up(db *gorm.DB) (int, error) {
+    if err := ensureTable(db); err != nil {
+        return 0, err
+    }
+    applied, err := appliedVersions(db)
+    if err != nil {
+        return 0, err
+    }
+
+    count := 0
+    for _, m := range migrations {
+        if _, ok := applied[m.Version]; ok {
+            continue
+        }
+        err := db.Transaction(func(tx *gorm.DB) error {
+            if err := m.Up(tx); err != nil {
+                return err
+            }
+            return tx.Create(&SchemaMigration{
+                Version:   m.Version,
+                Name:      m.Name,
+                AppliedAt: time.Now(),
+            }).Error
+        })
+        if err != nil {
+            return count, fmt.Errorf("migration %s_%s failed, %w", m.Version, m.Name, err)
+        }
+        log.Printf("INFO: Applied migration %s_%s", m.Version, m.Name)
+        count++
+    }
+    return count, nil
+}
+
+// Down revert the last `steps` applied migration, newest first.
+func Down(db *gorm.DB, steps int) (int, error) {
+    if steps <= 0 {
+        return 0, errors.New("steps need to be > 0")
+    }
+    if err := ensureTable(db); err != nil {
+        return 0, err
+    }
+    applied, err := appliedVersions(db)
+    if err != nil {
+        return 0, err
+    }
+
+    count := 0
+    for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+        m := migrations[i]
+        if _, ok := applied[m.Version]; !ok {
+            continue
+        }
+        err := db.Transaction(func(tx *gorm.DB) error {
+            if err := m.Down(tx); err != nil {
+                return err
+            }
+            return tx.Where("version = ?", m.Version).Delete(&SchemaMigration{}).Error
+        })
+        if err != nil {
+            return count, fmt.Errorf("revert %s_%s failed, %w", m.Version, m.Name, err)
+        }
+        log.Printf("INFO: Reverted migration %s_%s", m.Version, m.Name)
+        count++
+    }
+    return count, nil
+}
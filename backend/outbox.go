@@ -0,0 +1,165 @@
+package main
+
+import (
+    "encoding/json"
+    "log"
+    "strings"
+    "time"
+    "webrpl/table"
+)
+
+const outboxPollInterval = 10 * time.Second
+const outboxBatchSize = 20
+const outboxMaxAttempts = 8
+const outboxBaseBackoff = 30 * time.Second
+const outboxMaxBackoff = 2 * time.Hour
+
+// NOTE: Store the mail on the outbox and let the worker send it,
+//       so the request dont wait (or fail) on the smtp server.
+func queueEmail(backend *Backend, mail *Mail) (*table.EmailOutbox, error) {
+    attach := ""
+    if len(mail.Attachments) > 0 {
+        raw, err := json.Marshal(mail.Attachments)
+        if err != nil {
+            return nil, err
+        }
+        attach = string(raw)
+    }
+
+    entry := table.EmailOutbox{
+        EmailTo:        strings.Join(mail.To, ","),
+        EmailSubject:   mail.Subject,
+        EmailText:      mail.Text,
+        EmailHTML:      mail.HTML,
+        EmailAttach:    attach,
+        EmailStatus:    table.EmailPending,
+        EmailNextTry:   time.Now(),
+        EmailSensitive: mail.Sensitive,
+    }
+    if err := backend.db.Create(&entry).Error; err != nil {
+        return nil, err
+    }
+
+    wakeEmailWorker(backend)
+    return &entry, nil
+}
+
+func queueEmailTo(backend *Backend, to string, subject string, body string) error {
+    _, err := queueEmail(backend, &Mail{
+        To:      []string{to},
+        Subject: subject,
+        Text:    body,
+    })
+    return err
+}
+
+func wakeEmailWorker(backend *Backend) {
+    select {
+    case backend.outboxWake <- struct{}{}:
+    default:
+    }
+}
+
+func outboxBackoff(attempts int) time.Duration {
+    backoff := outboxBaseBackoff
+    for i := 1; i < attempts; i++ {
+        backoff *= 2
+        if backoff >= outboxMaxBackoff {
+            return outboxMaxBackoff
+        }
+    }
+    return backoff
+}
+
+func startEmailWorker(backend *Backend) {
+    // Anything left on sending is from a crash mid way, try it again.
+    res := backend.db.Model(&table.EmailOutbox{}).
+        Where("email_status = ?", table.EmailSending).
+        Update("email_status", table.EmailPending)
+    if res.Error != nil {
+        log.Printf("WARN: Failed to reset the sending outbox entries, %v", res.Error)
+    }
+
+    go func() {
+        ticker := time.NewTicker(outboxPollInterval)
+        defer ticker.Stop()
+        for {
+            processEmailOutbox(backend)
+            select {
+            case <-ticker.C:
+            case <-backend.outboxWake:
+            }
+        }
+    }()
+}
+
+func processEmailOutbox(backend *Backend) {
+    var entries []table.EmailOutbox
+    res := backend.db.
+        Where("email_status = ? AND email_next_try <= ?", table.EmailPending, time.Now()).
+        Order("email_next_try ASC").
+        Limit(outboxBatchSize).
+        Find(&entries)
+    if res.Error != nil {
+        log.Printf("WARN: Failed to fetch the email outbox, %v", res.Error)
+        return
+    }
+
+    for i := range entries {
+        entry := &entries[i]
+
+        // Claim it first so two instance dont send the same mail.
+        claim := backend.db.Model(&table.EmailOutbox{}).
+            Where("id = ? AND email_status = ?", entry.ID, table.EmailPending).
+            Update("email_status", table.EmailSending)
+        if claim.Error != nil || claim.RowsAffected == 0 {
+            continue
+        }
+
+        sendErr := sendOutboxEntry(backend, entry)
+        entry.EmailAttempts++
+
+        if sendErr == nil {
+            now := time.Now()
+            entry.EmailStatus = table.EmailSent
+            entry.EmailSentAt = &now
+            entry.EmailLastError = ""
+        } else {
+            log.Printf("WARN: Failed to send outbox email %d (attempt %d), %v", entry.ID, entry.EmailAttempts, sendErr)
+            entry.EmailLastError = sendErr.Error()
+            if entry.EmailAttempts >= outboxMaxAttempts {
+                entry.EmailStatus = table.EmailFailed
+            } else {
+                entry.EmailStatus = table.EmailPending
+                entry.EmailNextTry = time.Now().Add(outboxBackoff(entry.EmailAttempts))
+            }
+        }
+
+        columns := []string{"email_status", "email_attempts", "email_last_error", "email_next_try", "email_sent_at"}
+        // Nothing left to send, dont keep the secret around.
+        if entry.EmailSensitive && entry.EmailStatus != table.EmailPending {
+            entry.EmailText = ""
+            entry.EmailHTML = ""
+            columns = append(columns, "email_text", "email_html")
+        }
+        res := backend.db.Model(entry).Select(columns).Updates(entry)
+        if res.Error != nil {
+            log.Printf("WARN: Failed to save outbox email %d status, %v", entry.ID, res.Error)
+        }
+    }
+}
+
+func sendOutboxEntry(backend *Backend, entry *table.EmailOutbox) error {
+    mail := Mail{
+        To:      strings.Split(entry.EmailTo, ","),
+        Subject: entry.EmailSubject,
+        Text:    entry.EmailText,
+        HTML:    entry.EmailHTML,
+    }
+    if entry.EmailAttach != "" {
+        if err := json.Unmarshal([]byte(entry.EmailAttach), &mail.Attachments); err != nil {
+            return err
+        }
+    }
+    return backend.mailer.Send(&mail)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: Fixed window counter kept in memory, so it reset on restart and is
+//       not shared between instance. Good enough for a single backend.
+type RateLimiter struct {
+    mu      sync.Mutex
+    max     int
+    window  time.Duration
+    hits    map[string]*rateWindow
+    sweepAt time.Time
+}
+
+type rateWindow struct {
+    count int
+    reset time.Time
+}
+
+// max <= 0 mean the limiter always allow.
+func NewRateLimiter(max int, window time.Duration) *RateLimiter {
+    return &RateLimiter{
+        max:    max,
+        window: window,
+        hits:   map[string]*rateWindow{},
+    }
+}
+
+// Allow count one hit for the key if it still have room, the check and the
+// count are under the same lock so parallel request cant all slip through.
+// Return how long until the window reset when it is refused.
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+    if rl.max <= 0 {
+        return true, 0
+    }
+
+    rl.mu.Lock()
+    defer rl.mu.Unlock()
+
+    now := time.Now()
+    rl.sweep(now)
+
+    hit, ok := rl.hits[key]
+    if !ok || !now.Before(hit.reset) {
+        hit = &rateWindow{reset: now.Add(rl.window)}
+        rl.hits[key] = hit
+    }
+    if hit.count >= rl.max {
+        return false, hit.reset.Sub(now)
+    }
+    hit.count++
+    return true, 0
+}
+
+// Refund give back one hit taken by Allow, for a request that turned out
+// not to count. Nothing happen if the window already reset.
+func (rl *RateLimiter) Refund(key string) {
+    if rl.max <= 0 {
+        return
+    }
+
+    rl.mu.Lock()
+    defer rl.mu.Unlock()
+
+    hit, ok := rl.hits[key]
+    if ok && time.Now().Before(hit.reset) && hit.count > 0 {
+        hit.count--
+    }
+}
+
+// NOTE: Drop the expired window once in a while so the map dont grow forever.
+func (rl *RateLimiter) sweep(now time.Time) {
+    if now.Before(rl.sweepAt) {
+        return
+    }
+    for key, hit := range rl.hits {
+        if !now.Before(hit.reset) {
+            delete(rl.hits, key)
+        }
+    }
+    rl.sweepAt = now.Add(rl.window)
+}
+
+// One limiter for the ip and one for the email, so spreading over many email
+// or many ip both get caught.
+type RequestLimiter struct {
+    ip    *RateLimiter
+    email *RateLimiter
+}
+
+func NewRequestLimiter(conf RateLimitConfig) *RequestLimiter {
+    return &RequestLimiter{
+        ip:    NewRateLimiter(conf.PerIP, conf.Window),
+        email: NewRateLimiter(conf.PerEmail, conf.Window),
+    }
+}
+
+// Reserve one hit on the ip and on the email (when not empty), false and
+// Retry-After set when either is used up. A refused request dont keep any
+// hit, so the ip is refunded when only the email refuse it.
+func (rl *RequestLimiter) Allow(c *fiber.Ctx, email string) bool {
+    okIP, wait := rl.ip.Allow(c.IP())
+    if okIP && email != "" {
+        var okEmail bool
+        okEmail, wait = rl.email.Allow(strings.ToLower(email))
+        if !okEmail {
+            rl.ip.Refund(c.IP())
+            okIP = false
+        }
+    }
+    if okIP {
+        return true
+    }
+
+    c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+    return false
+}
+
+// Refund the hit of a request that was allowed but should not count (a
+// successful login).
+func (rl *RequestLimiter) Refund(c *fiber.Ctx, email string) {
+    rl.ip.Refund(c.IP())
+    if email != "" {
+        rl.email.Refund(strings.ToLower(email))
+    }
+}
+
+type Limiters struct {
+    login  *RequestLimiter
+    otp    *RequestLimiter
+    verify *RequestLimiter
+}
+
+func newLimiters(conf *SecurityConfig) Limiters {
+    return Limiters{
+        login:  NewRequestLimiter(conf.LoginLimit),
+        otp:    NewRequestLimiter(conf.OTPLimit),
+        verify: NewRequestLimiter(conf.VerifyLimit),
+    }
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "sync/atomic"
+    "time"
+    "webrpl/table"
+
+    "github.com/robfig/cron/v3"
+    "gorm.io/gorm"
+)
+
+var (
+    errJobNotFound = errors.New("job not found")
+    errJobRunning  = errors.New("job is already running")
+)
+
+// NOTE: Run return a short summary for the job_runs table, eg. `deleted 3 otp`.
+type Job struct {
+    Name    string
+    Spec    string
+    Run     func(backend *Backend) (string, error)
+    entry   cron.EntryID
+    running atomic.Bool
+}
+
+// NOTE: Every instance run its own scheduler, the job are written so running
+//       them twice at the same time is harmless (conditional update, delete).
+type Scheduler struct {
+    cron *cron.Cron
+    jobs []*Job
+}
+
+func newScheduler(config *JobsConfig) *Scheduler {
+    return &Scheduler{
+        cron: cron.New(),
+        jobs: []*Job{
+            {Name: "otp_cleanup", Spec: config.OTPCleanup, Run: jobOTPCleanup},
+            {Name: "session_cleanup", Spec: config.SessionCleanup, Run: jobSessionCleanup},
+            {Name: "orphan_cleanup", Spec: config.OrphanCleanup, Run: jobOrphanCleanup},
+            {Name: "event_reminder", Spec: config.EventReminder, Run: jobEventReminder},
+            {Name: "certificate_issue", Spec: config.CertIssue, Run: jobCertificateIssue},
+            {Name: "certificate_email", Spec: config.CertEmail, Run: jobCertificateEmail},
+        },
+    }
+}
+
+func (s *Scheduler) Job(name string) *Job {
+    for _, job := range s.jobs {
+        if job.Name == name {
+            return job
+        }
+    }
+    return nil
+}
+
+// Next scheduled run, nil when the job only run by hand or the scheduler is not started.
+func (s *Scheduler) Next(job *Job) *time.Time {
+    if job.entry == 0 {
+        return nil
+    }
+    next := s.cron.Entry(job.entry).Next
+    if next.IsZero() {
+        return nil
+    }
+    return &next
+}
+
+func startScheduler(backend *Backend) {
+    s := backend.scheduler
+    for _, job := range s.jobs {
+        if job.Spec == "" {
+            continue
+        }
+        // NOTE: The spec is already checked by Config.Validate.
+        id, err := s.cron.AddFunc(job.Spec, func() {
+            if _, err := runJob(backend, job, false); err != nil && !errors.Is(err, errJobRunning) {
+                log.Printf("WARN: Job %s failed, %v", job.Name, err)
+            }
+        })
+        if err != nil {
+            log.Printf("WARN: Failed to schedule job %s, %v", job.Name, err)
+            continue
+        }
+        job.entry = id
+    }
+    s.cron.Start()
+}
+
+// Run the job now and record the outcome, a job never run twice at once on
+// the same instance.
+func runJob(backend *Backend, job *Job, manual bool) (*table.JobRun, error) {
+    if !job.running.CompareAndSwap(false, true) {
+        return nil, errJobRunning
+    }
+    defer job.running.Store(false)
+
+    started := time.Now()
+    recordJobRun(backend, job.Name, func(run *table.JobRun) {
+        run.JobStatus = table.JobRunning
+        run.JobManual = manual
+        run.JobStartedAt = &started
+    })
+
+    message, err := runJobSafe(backend, job)
+
+    ended := time.Now()
+    run := recordJobRun(backend, job.Name, func(run *table.JobRun) {
+        run.JobEndedAt = &ended
+        run.JobRunCount++
+        if err != nil {
+            run.JobStatus = table.JobFailed
+            run.JobMessage = err.Error()
+            run.JobFailCount++
+            return
+        }
+        run.JobStatus = table.JobOK
+        run.JobMessage = message
+    })
+    if err == nil {
+        log.Printf("INFO: Job %s done in %s, %s", job.Name, ended.Sub(started).Round(time.Millisecond), message)
+    }
+    return run, err
+}
+
+// NOTE: A panic on a job should not take the whole server with it.
+func runJobSafe(backend *Backend, job *Job) (message string, err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("panic, %v", r)
+        }
+    }()
+    return job.Run(backend)
+}
+
+func recordJobRun(backend *Backend, name string, update func(run *table.JobRun)) *table.JobRun {
+    var run table.JobRun
+    res := backend.db.Where("job_name = ?", name).First(&run)
+    if res.Error != nil && !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+        log.Printf("WARN: Failed to fetch the run of job %s, %v", name, res.Error)
+        return nil
+    }
+
+    run.JobName = name
+    update(&run)
+    if err := backend.db.Save(&run).Error; err != nil {
+        log.Printf("WARN: Failed to save the run of job %s, %v", name, err)
+    }
+    return &run
+}
@@ -1,7 +0,0 @@
-package main
-
-type SecretHolder struct {
-    Password string
-    Email string
-    EmailAppPassword string
-}
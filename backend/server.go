@@ -1,9 +1,6 @@
 package main
 
 import (
-	"math/rand"
-	"time"
-
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -13,36 +10,40 @@ type Backend struct {
     app       *fiber.App
     db        *gorm.DB
     pass      string
-    rand      *rand.Rand
     engine    *DynamicEngine
-    address   string
-    email     string
-    mode      string
-    emailpass string
+    config    *Config
+    mailer    Mailer
+    limiters  Limiters
+    scheduler *Scheduler
+
+    outboxWake chan struct{}
 }
 
-func appCreateNewServer(db *gorm.DB, sec SecretHolder, address string) *Backend {
-    secret := sec.Password
-    rand_t := rand.New(rand.NewSource(time.Now().UnixNano()))
+func appCreateNewServer(db *gorm.DB, config *Config, mailer Mailer) *Backend {
+    secret := config.JWTSecret
     engine := NewDynamicEngine([]string{
-        "./static-hidden/",
-        "./static/",
-}, ".html")
+        config.Upload.HiddenDir,
+        config.Upload.StaticDir,
+}, ".html", config.Upload.PartialsDir)
+    for name, fn := range certTemplateFuncs(config.Locale) {
+        engine.AddFunc(name, fn)
+    }
     app := fiber.New(fiber.Config{
         AppName: "Webinar-RPL Backend",
         Views: engine,
+        ProxyHeader: config.Security.ProxyHeader,
     })
 
     return &Backend{
         app:   app,
         db:    db,
         pass: secret,
-        rand: rand_t,
         engine: engine,
-        address: address,
-        mode: "http",
-        email: sec.Email,
-        emailpass: sec.EmailAppPassword,
+        config: config,
+        mailer: mailer,
+        limiters: newLimiters(&config.Security),
+        scheduler: newScheduler(&config.Jobs),
+        outboxWake: make(chan struct{}, 1),
     }
 }
 
@@ -50,17 +51,26 @@ func appMakeRouteHandler(backend *Backend) {
     app := backend.app
     api := app.Group("/api")
 
+    // NOTE: Must be before the group below, fiber match a group middleware by
+    //       prefix so `/api/c` also catch `/api/certificate`.
+    appHandleCertificatePDF(backend, api)
+    appHandleCertificateRoom(backend, api)
+    appHandleCertificateQR(backend, api)
+    appHandleCertificateVerify(backend, api)
+
     protected := api.Group("/protected", jwtware.New(jwtware.Config{
         SigningKey: jwtware.SigningKey{Key: []byte(backend.pass)},
+        SuccessHandler: sessionCheck(backend),
     }))
 
     cookieJWT := api.Group("/c", jwtware.New(jwtware.Config{
         SigningKey:  jwtware.SigningKey{Key: []byte(backend.pass)},
         TokenLookup: "cookie:jwt",
         ContextKey:  "user",
+        SuccessHandler: sessionCheck(backend),
     }))
 
-    app.Static("/static", "./static")
+    app.Static("/static", backend.config.Upload.StaticDir)
 
     // USER STUFF
     appHandleLogin(backend, api)
@@ -80,6 +90,17 @@ func appMakeRouteHandler(backend *Backend) {
     appHandleUserLogOut(backend, protected)
     appHandleUserLogOut(backend, cookieJWT)
 
+    // SESSION STUFF
+    appHandleTokenRefresh(backend, api)
+    appHandleSessionList(backend, protected)
+    appHandleSessionRevoke(backend, protected)
+    appHandleLogOutAll(backend, protected)
+    appHandleLogOutAll(backend, cookieJWT)
+
+    // LOCKOUT STUFF
+    appHandleLockoutList(backend, protected)
+    appHandleUserUnlock(backend, protected)
+
     // EVENT STUFF
     appHandleEventInfoAll(backend, protected)
     appHandleEventInfoOf(backend, protected)
@@ -96,22 +117,39 @@ func appMakeRouteHandler(backend *Backend) {
     appHandleMaterialEdit(backend, protected)
 
     // CERTIFICATE TEMPLATE STUFF
-    appHandleCertificateRoom(backend, api)
     appHandleCertTempNew(backend, protected)
     appHandleCertTempInfoOf(backend, protected)
     appHandleCertDel(backend, protected)
     appHandleCertEdit(backend, protected)
     appHandleCertUploadTemplate(backend, protected)
 
+    appHandleCertificateList(backend, protected)
+    appHandleCertificateRevoke(backend, protected)
+    appHandleCertificateReissue(backend, protected)
+    appHandleCertificateExport(backend, protected)
+    appHandleCertificateEmail(backend, protected)
+
     appHandleCertNewDumb(backend, protected)
 
+    appHandleCertLibraryList(backend, protected)
+    appHandleCertLibraryUpload(backend, protected)
+    appHandleCertLibraryPreview(backend, protected)
+    appHandleCertLibraryDelete(backend, protected)
+    appHandleCertLibraryUse(backend, protected)
+
     appHandleCertEditor(backend, cookieJWT)
     appHandleCertEditorUploadImage(backend, cookieJWT)
     appHandleCertEditorUploadHtml(backend , cookieJWT)
+    appHandleCertTemplatePreview(backend, cookieJWT)
+    appHandleCertTemplatePlaceholders(backend, cookieJWT)
 
     appHandleCertEditor(backend, protected)
     appHandleCertEditorUploadImage(backend, protected)
     appHandleCertEditorUploadHtml(backend , protected)
+    appHandleCertTemplatePreview(backend, protected)
+    appHandleCertTemplatePlaceholders(backend, protected)
+    appHandleCertTemplateVersions(backend, protected)
+    appHandleCertTemplateActivate(backend, protected)
 
     // EVENT PARTICIPANT STUFF
     appHandleEventParticipateRegister(backend, protected)
@@ -124,11 +162,30 @@ func appMakeRouteHandler(backend *Backend) {
     appHandleEventParticipateOfEventCount(backend, protected)
     appHandleEventParticipateAbsenceBulk(backend, protected)
     appHandleEventParticipateAbsenceItself(backend, protected)
+    appHandleEventParticipateCancel(backend, protected)
+    appHandleEventParticipateWaitlist(backend, protected)
+    appHandleEventParticipateWaitlistReorder(backend, protected)
+    appHandleEventParticipateWaitlistPromote(backend, protected)
+    appHandleEventParticipateExport(backend, protected)
+    appHandleEventParticipatePending(backend, protected)
+    appHandleEventParticipateApprove(backend, protected)
+    appHandleEventParticipateReject(backend, protected)
 
     // OTP STUFF
     appHandleGenOTP(backend, api)
     appHandleCleanupOTP(backend, protected)
 
+    // JOB STUFF
+    appHandleJobList(backend, protected)
+    appHandleJobRun(backend, protected)
+
+    // EMAIL OUTBOX STUFF
+    appHandleEmailOutboxList(backend, protected)
+    appHandleEmailOutboxRequeue(backend, protected)
+
+    // TEMPLATE ENGINE STUFF
+    appHandleEngineStats(backend, protected)
+
     app.Get("/", func(c *fiber.Ctx) error {
         return c.SendString("Server is running.")
     })
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +14,6 @@ import (
 	"webrpl/table"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
@@ -21,7 +21,7 @@ import (
 // POST : api/protected/cert-register
 func appHandleCertTempNew(backend *Backend, route fiber.Router) {
     route.Post("cert-register", func (c *fiber.Ctx) error {
-        user := c.Locals("user").(*jwt.Token)
+        user, err := requireRole(c, table.RoleAdmin)
         if user == nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -31,10 +31,7 @@ func appHandleCertTempNew(backend *Backend, route fiber.Router) {
             })
         }
 
-        claims := user.Claims.(jwt.MapClaims)
-        isAdmin := claims["admin"].(float64)
-
-        if isAdmin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials for this function",
@@ -48,7 +45,7 @@ func appHandleCertTempNew(backend *Backend, route fiber.Router) {
             CertTemplate  string `json:"cert_temp"`
         }
 
-        err := c.BodyParser(&body)
+        err = c.BodyParser(&body)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -96,7 +93,7 @@ func appHandleCertTempNew(backend *Backend, route fiber.Router) {
 // GET : api/protected/cert-info-of
 func appHandleCertTempInfoOf(backend *Backend, route fiber.Router) {
 	route.Get("cert-info-of", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -106,7 +103,7 @@ func appHandleCertTempInfoOf(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         if email == "" {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -161,7 +158,7 @@ func appHandleCertTempInfoOf(backend *Backend, route fiber.Router) {
 // POST : api/protected/cert-del
 func appHandleCertDel(backend *Backend, route fiber.Router) {
     route.Post("cert-del", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -171,16 +168,6 @@ func appHandleCertDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-        if isAdmin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid credentials for this function",
-                "error_code": 2,
-                "data": nil,
-            })
-        }
-
         var body struct {
             CertTempID int `json:"id"`
         }
@@ -195,7 +182,30 @@ func appHandleCertDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        res := backend.db.Delete(&table.CertTemplate{}, body.CertTempID)
+        var certTemp table.CertTemplate
+        res := backend.db.First(&certTemp, body.CertTempID)
+        if res.Error != nil && !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Failed to delete certificate template from the DB.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        if res.Error == nil {
+            _, err = requireEventRole(backend, c, certTemp.EventId, table.CommitteeU)
+            if err != nil {
+                return c.Status(authzStatus(err)).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Invalid credentials for this function",
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
+        }
+
+        res = backend.db.Delete(&table.CertTemplate{}, body.CertTempID)
         if res.Error != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -217,7 +227,7 @@ func appHandleCertDel(backend *Backend, route fiber.Router) {
 // POST : api/protected/cert-edit
 func appHandleCertEdit(backend *Backend, route fiber.Router) {
     route.Post("cert-edit", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -227,17 +237,6 @@ func appHandleCertEdit(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-
-        if isAdmin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid credentials for this function",
-                "error_code": 2,
-                "data": nil,
-            })
-        }
-
         var body struct {
             CertTempID int    `json:"id"`
             NewPath    string `json:"cert_path"`
@@ -264,6 +263,16 @@ func appHandleCertEdit(backend *Backend, route fiber.Router) {
 			})
 		}
 
+        _, err = requireEventRole(backend, c, certTemp.EventId, table.CommitteeU)
+        if err != nil {
+            return c.Status(authzStatus(err)).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials for this function",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
         if body.NewPath == "" {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
@@ -273,7 +282,9 @@ func appHandleCertEdit(backend *Backend, route fiber.Router) {
 			})
         }
 
+        // NOTE: A hand set path is not one of the version anymore.
         certTemp.CertTemplate = body.NewPath
+        certTemp.CertActiveVersion = nil
 		result = backend.db.Save(&certTemp)
         if result.Error != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -293,14 +304,14 @@ func appHandleCertEdit(backend *Backend, route fiber.Router) {
     })
 }
 
-// IMPORTANT -- DEPRECATED SHOULD NO BE USED. --
+// IMPORTANT -- DEPRECATED SHOULD NO BE USED. -- use cert-library-upload.
 // NOTE: @@ -> $bg.png.path
 // NOTE: data_html, data_img
 // POST : api/protected/cert-upload-template
 func appHandleCertUploadTemplate(backend *Backend, route fiber.Router) {
 	route.Post("cert-upload-template", func (c *fiber.Ctx) error {
-		claims, err := GetJWT(c)
-		if err != nil {
+		user, err := requireRole(c, table.RoleAdmin)
+		if user == nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"success": false,
 				"message": "Invalid JWT Token.",
@@ -308,8 +319,7 @@ func appHandleCertUploadTemplate(backend *Backend, route fiber.Router) {
 				"data": nil,
 			})
 		}
-		admin := claims["admin"].(float64)
-		if admin != 1 {
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
 				"message": "Invalid credentials for this function",
@@ -343,7 +353,7 @@ func appHandleCertUploadTemplate(backend *Backend, route fiber.Router) {
 			})
 		}
 
-		certDir := "static-hidden"
+		certDir := backend.config.Upload.HiddenDir
 		if err := os.MkdirAll(certDir, 0755); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
@@ -413,7 +423,7 @@ func appHandleCertUploadTemplate(backend *Backend, route fiber.Router) {
 
 		htmlFilename := fmt.Sprintf("%s/index.html", certTempDir)
 
-        htmlDataProcessed := strings.ReplaceAll(string(htmlData), "@@", fmt.Sprintf("%s://%s/%s/bg.png", backend.mode, backend.address, certTempDir))
+        htmlDataProcessed := strings.ReplaceAll(string(htmlData), "@@", publicURL(backend, fmt.Sprintf("static-hidden/%s/bg.png", body.FileName)))
 
 		err = os.WriteFile(htmlFilename, []byte(htmlDataProcessed), 0644)
 		if err != nil {
@@ -452,65 +462,220 @@ func appHandleCertificateRoom(backend *Backend, route fiber.Router) {
 	route.Get("certificate/:base64", func (c *fiber.Ctx) error {
 		base64Param := c.Params("base64")
 
-        var evPart table.EventParticipant
-        res := backend.db.Preload("User").Preload("Event").Where(&table.EventParticipant{EventPCode: base64Param, EventPCome: true}).First(&evPart)
+        evPart, files, cert, err := certificateFor(backend, c, base64Param)
+        if evPart == nil {
+            return err
+        }
 
-        if res.Error != nil {
-            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
-                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Render(files.Name, certificateData(backend, evPart, cert))
+	})
+}
+
+// NOTE: Same certificate as the html one but rendered on the server, so the
+//       user dont need a browser that print it right. Cached per code.
+// GET : api/certificate/:base64.pdf
+func appHandleCertificatePDF(backend *Backend, route fiber.Router) {
+	route.Get("certificate/:base64.pdf", func (c *fiber.Ctx) error {
+		base64Param := c.Params("base64")
+
+        evPart, files, cert, err := certificateFor(backend, c, base64Param)
+        if evPart == nil {
+            return err
+        }
+
+        pdfPath, err := certificatePDF(backend, evPart, cert, files)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to render the certificate, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        c.Set(fiber.HeaderContentType, "application/pdf")
+        c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"certificate-%s.pdf\"", base64Param))
+        return c.SendFile(pdfPath)
+	})
+}
+
+// NOTE: The check shared by the html and pdf certificate, when the participant
+//       is nil the error response is already written and err is what the
+//       handler should return.
+func certificateFor(backend *Backend, c *fiber.Ctx, code string) (*table.EventParticipant, *certTemplateFiles, *table.Certificate, error) {
+    evPart, err := findCertParticipant(backend, code)
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Failed to get the cert for that code",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        return nil, nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": fmt.Sprintf("Failed to fetch event participant for this code, %v", err),
+            "error_code": 1,
+            "data": nil,
+        })
+    }
+
+    now := time.Now()
+    if evPart.Event.EventDEnd.After(now) {
+        return nil, nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "success": false,
+            "message": "The event is not done yet.",
+            "error_code": 3,
+            "data": nil,
+        })
+    }
+
+    files, err := resolveCertTemplate(backend, evPart.EventId)
+    if err != nil {
+        if errors.Is(err, errCertTemplateMissing) {
+            return nil, nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("The Certificate template file didnt exist, Please contact the committee or admin to add them. DEBUG PURPOSE: %s", files.HTMLPath),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+        return nil, nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": fmt.Sprintf("Failed to fetch certificate template from the db, %v", err),
+            "error_code": 2,
+            "data": nil,
+        })
+    }
+
+    cert, err := currentCertificate(backend, evPart)
+    if err != nil {
+        if errors.Is(err, errCertRevoked) {
+            return nil, nil, nil, c.Status(fiber.StatusGone).JSON(fiber.Map{
+                "success": false,
+                "message": "This certificate has been revoked.",
+                "error_code": 6,
+                "data": fiber.Map{
+                    "serial": cert.CertSerial,
+                    "revoked_at": cert.CertRevokedAt,
+                    "reason": cert.CertRevokeReason,
+                },
+            })
+        }
+        return nil, nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": fmt.Sprintf("Failed to issue the certificate, %v", err),
+            "error_code": 7,
+            "data": nil,
+        })
+    }
+
+    return evPart, files, cert, nil
+}
+
+// NOTE: Public, for anyone holding a certificate to check it. A forged code
+//       and a code of a participant without certificate get the same answer.
+// GET : api/certificate-verify/:code
+func appHandleCertificateVerify(backend *Backend, route fiber.Router) {
+    route.Get("certificate-verify/:code", func (c *fiber.Ctx) error {
+        evPart, cert, err := findVerifiedCertificate(backend, c.Params("code"))
+        if err != nil {
+            if errors.Is(err, errCertInvalid) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
                     "success": false,
-                    "message": "Failed to get the cert for that code",
-                    "error_code": 4,
+                    "message": "This certificate is not valid.",
+                    "error_code": 1,
                     "data": nil,
                 })
             }
+            if errors.Is(err, errCertRevoked) {
+                return c.Status(fiber.StatusGone).JSON(fiber.Map{
+                    "success": false,
+                    "message": "This certificate has been revoked.",
+                    "error_code": 3,
+                    "data": fiber.Map{
+                        "user_name": evPart.User.UserFullName,
+                        "event_name": evPart.Event.EventName,
+                        "serial": cert.CertSerial,
+                        "revoked_at": cert.CertRevokedAt,
+                        "reason": cert.CertRevokeReason,
+                    },
+                })
+            }
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to fetch event participant for this code, %v", res.Error),
-                "error_code": 1,
+                "message": fmt.Sprintf("Failed to fetch the certificate, %v", err),
+                "error_code": 2,
                 "data": nil,
             })
         }
 
-        now := time.Now()
-        if evPart.Event.EventDEnd.After(now) {
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "This certificate is valid.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "user_name": evPart.User.UserFullName,
+                "event_id": evPart.EventId,
+                "event_name": evPart.Event.EventName,
+                "event_start": evPart.Event.EventDStart,
+                "event_end": evPart.Event.EventDEnd,
+                "serial": cert.CertSerial,
+                "issued_at": cert.CertIssuedAt,
+            },
+        })
+    })
+}
+
+// NOTE: The qr point to api/certificate-verify/:code, a template use it with
+//       `<img src="{{ .VerifyQR }}">`. size is in pixel, 256 by default.
+// GET : api/certificate-verify/:code/qr.png
+func appHandleCertificateQR(backend *Backend, route fiber.Router) {
+    route.Get("certificate-verify/:code/qr.png", func (c *fiber.Ctx) error {
+        size, err := strconv.Atoi(c.Query("size", "256"))
+        if err != nil || size < 64 || size > 1024 {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "The event is not done yet.",
+                "message": "Invalid size on query, must be between 64 and 1024.",
                 "error_code": 3,
                 "data": nil,
             })
         }
 
-        var cerTemp table.CertTemplate
-        res = backend.db.Where("event_id = ?", evPart.EventId).First(&cerTemp)
-        if res.Error != nil {
+        // NOTE: A revoked certificate still get its qr, the verify page say it is revoked.
+        evPart, cert, err := findVerifiedCertificate(backend, c.Params("code"))
+        if err != nil && !errors.Is(err, errCertRevoked) {
+            if errors.Is(err, errCertInvalid) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "This certificate is not valid.",
+                    "error_code": 1,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to fetch certificate template from the db, %v", res.Error),
+                "message": fmt.Sprintf("Failed to fetch the certificate, %v", err),
                 "error_code": 2,
                 "data": nil,
             })
         }
 
-        if _, err := os.Stat(fmt.Sprintf("./static/%s", cerTemp.CertTemplate)); os.IsNotExist(err) {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        png, err := certificateQR(backend, evPart, cert, size)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("The Certificate template file didnt exist, Please contact the committee or admin to add them. DEBUG PURPOSE: %s", fmt.Sprintf("./static/%s", cerTemp.CertTemplate)),
-                "error_code": 3,
+                "message": fmt.Sprintf("Failed to make the qr code, %v", err),
+                "error_code": 4,
                 "data": nil,
             })
         }
 
-        // Strip the .html from the cerTemp
-        stripped := strings.TrimSuffix(cerTemp.CertTemplate, ".html")
-
-		return c.Render(stripped, fiber.Map{
-			"UniqueID": base64Param,
-            "EventName": evPart.Event.EventName,
-			"UserName": evPart.User.UserFullName,
-		})
-	})
+        c.Set(fiber.HeaderContentType, "image/png")
+        c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+        return c.Send(png)
+    })
 }
 
 // new but dumb stuff
@@ -519,7 +684,7 @@ func appHandleCertificateRoom(backend *Backend, route fiber.Router) {
 // POST : api/protected/create-new-cert-from-event
 func appHandleCertNewDumb(backend *Backend, route fiber.Router) {
     route.Post("create-new-cert-from-event", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c);
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -529,10 +694,7 @@ func appHandleCertNewDumb(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
-        admin := claims["admin"].(float64)
-
-        if email == "" {
+        if user.UserEmail == "" {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid email on JWT.",
@@ -542,7 +704,8 @@ func appHandleCertNewDumb(backend *Backend, route fiber.Router) {
         }
 
         var body struct {
-            EventID int `json:"event_id"`
+            EventID   int `json:"event_id"`
+            LibraryID int `json:"library_id"`
         }
 
         err = c.BodyParser(&body)
@@ -555,45 +718,58 @@ func appHandleCertNewDumb(backend *Backend, route fiber.Router) {
             })
         }
 
-        var currentUser table.User
-        res := backend.db.Where("user_email = ?", email).First(&currentUser)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+        _, err = requireEventRole(backend, c, body.EventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("There is a problem with the db, %v", err),
+                    "error_code": 5,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to fetch user from db, %v", res.Error),
-                "error_code": 4,
+                "message": "Invalid credentials for this function",
+                "error_code": 6,
                 "data": nil,
             })
         }
 
-        var currentEvPart table.EventParticipant
-        if admin != 1 {
-            res = backend.db.Where("user_id = ? AND event_id = ?", currentUser.ID, body.EventID).First(&currentEvPart)
-            if res.Error != nil {
-                if errors.Is(res.Error, gorm.ErrRecordNotFound) {
-                    return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-                        "success": false,
-                        "message": "User is not registered on event participant.",
-                        "error_code": 8,
-                        "data": nil,
-                    })
-                } else {
-                    return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                        "success": false,
-                        "message": fmt.Sprintf("There is a problem with the db, %v", res.Error),
-                        "error_code": 5,
-                        "data": nil,
-                    })
-                }
+        // NOTE: Start from a library template instead of an empty editor.
+        if body.LibraryID != 0 {
+            var lib table.CertLibrary
+            res := backend.db.Where("id = ?", body.LibraryID).Limit(1).Find(&lib)
+            if res.Error != nil || res.RowsAffected == 0 {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Library template not found.",
+                    "error_code": 8,
+                    "data": nil,
+                })
             }
-            if currentEvPart.EventPRole != "committee" {
-                return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+
+            certTemp, err := certTemplateOfEvent(backend, body.EventID)
+            if err == nil {
+                _, err = useCertLibrary(backend, &lib, certTemp, &user.ID)
+            }
+            if err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                     "success": false,
-                    "message": "Invalid credentials for this function",
-                    "error_code": 6,
+                    "message": fmt.Sprintf("Failed to create new event cert template, %v", err),
+                    "error_code": 7,
                     "data": nil,
                 })
             }
+
+            return c.Status(fiber.StatusOK).JSON(fiber.Map{
+                "success": true,
+                "message": "Check data. Please access the editor link with the id this api return.",
+                "error_code": 0,
+                "data": fiber.Map{
+                    "id": body.EventID,
+                },
+            })
         }
 
         // straight up set the the cert path to nonexistance index.html
@@ -604,7 +780,7 @@ func appHandleCertNewDumb(backend *Backend, route fiber.Router) {
             CertTemplate: cert_path,
         }
 
-        res = backend.db.Save(&newCertTemplate)
+        res := backend.db.Save(&newCertTemplate)
         if res.Error != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -630,7 +806,7 @@ func appHandleCertNewDumb(backend *Backend, route fiber.Router) {
 // GET : api/c/cert-editor
 func appHandleCertEditor(backend *Backend, route fiber.Router) {
     route.Get("cert-editor", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -639,33 +815,23 @@ func appHandleCertEditor(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
-        email := claims["email"].(string)
 
-        if admin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid credentials for this function",
-                "error_code": 2,
-                "data": nil,
-            })
-        }
-
-        if email == "" {
+        event_id, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Invalid email on JWT.",
-                "error_code": 3,
+                "message": "Invalid event_id on query.",
+                "error_code": 4,
                 "data": nil,
             })
         }
 
-        event_id := c.Query("event_id")
-        if event_id == "" {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        _, err = requireEventRole(backend, c, event_id, table.CommitteeU)
+        if err != nil {
+            return c.Status(authzStatus(err)).JSON(fiber.Map{
                 "success": false,
-                "message": "Invalid event_id on query.",
-                "error_code": 4,
+                "message": "Invalid credentials for this function",
+                "error_code": 2,
                 "data": nil,
             })
         }
@@ -682,7 +848,7 @@ func appHandleCertEditor(backend *Backend, route fiber.Router) {
         }
 
         return c.Render("editor", fiber.Map{
-            "APIPath": fmt.Sprintf("%s://%s", backend.mode, backend.address),
+            "APIPath": backend.config.PublicBaseURL,
         })
     })
 }
@@ -693,7 +859,7 @@ func appHandleCertEditor(backend *Backend, route fiber.Router) {
 // POST : api/c/-cert-editor-upload-image
 func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
     route.Post("-cert-editor-upload-image", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -703,29 +869,6 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        email := claims["email"].(string)
-
-        if email == "" {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid empty email.",
-                "error_code": 3,
-                "data": nil,
-            })
-        }
-
-        var currentUser table.User
-        res := backend.db.Where("user_email = ?", email).First(&currentUser)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": fmt.Sprintf("Failed to get the user with that email from the db, %v", err),
-                "error_code": 9,
-                "data": nil,
-            })
-        }
-
         var body struct {
             Data      string `json:"data"`
             EventID   string `json:"event_id"`
@@ -741,18 +884,27 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
             })
         }
 
-        var currentEventPart table.EventParticipant
-        res = backend.db.Where("user_id = ? AND event_id = ?", currentUser.ID, body.EventID).First(&currentEventPart)
-        if res.Error != nil && admin != 1 {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+        // NOTE: event_id end up on the file path, so it must be a real number.
+        eventID, err := strconv.Atoi(body.EventID)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to get the event participant with that user and event from the db, %v", res.Error),
-                "error_code": 10,
+                "message": fmt.Sprintf("Invalid event_id, %v", err),
+                "error_code": 4,
                 "data": nil,
             })
         }
 
-        if admin != 1 && currentEventPart.EventPRole != "committee" {
+        _, err = requireEventRole(backend, c, eventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to get the event participant with that user and event from the db, %v", err),
+                    "error_code": 10,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials for this function",
@@ -770,7 +922,7 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
 			})
 		}
 
-		certDir := "static"
+		certDir := backend.config.Upload.StaticDir
 		if err := os.MkdirAll(certDir, 0755); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
@@ -804,8 +956,10 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
             })
         }
 
-		certTempDir := fmt.Sprintf("%s/%s", certDir, body.EventID)
-		if err := os.MkdirAll(certTempDir, 0755); err != nil {
+        // NOTE: Only a draft, it become part of the template on the next html
+        //       save, so the active certificate is not touched.
+        imgFilename := certTemplateDraftBG(backend, eventID)
+		if err := os.MkdirAll(filepath.Dir(imgFilename), 0755); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
 				"message": "Failed to create certificate template directory",
@@ -814,7 +968,6 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
 			})
 		}
 
-        imgFilename := fmt.Sprintf("%s/bg.png", certTempDir)
 		err = os.WriteFile(imgFilename, decoded, 0644)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -825,14 +978,12 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
 			})
 		}
 
-        // NOTE: I think putting here is the best way.
-        backend.engine.ClearCache()
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "Image Uploaded successfully.",
             "error_code": 0,
             "data": fiber.Map{
-                "filename": fmt.Sprintf("%s://%s/%s", backend.mode, backend.address, imgFilename),
+                "filename": publicURL(backend, certTemplateDraftURL(eventID)),
             },
         })
     })
@@ -844,7 +995,7 @@ func appHandleCertEditorUploadImage(backend *Backend, route fiber.Router) {
 // POST : api/c/-cert-editor-upload-html
 func appHandleCertEditorUploadHtml(backend *Backend, route fiber.Router) {
     route.Post("-cert-editor-upload-html", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -854,29 +1005,6 @@ func appHandleCertEditorUploadHtml(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        email := claims["email"].(string)
-
-        if email == "" {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid empty email.",
-                "error_code": 3,
-                "data": nil,
-            })
-        }
-
-        var currentUser table.User
-        res := backend.db.Where("user_email = ?", email).First(&currentUser)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": fmt.Sprintf("Failed to get the user with that email from the db, %v", res.Error),
-                "error_code": 9,
-                "data": nil,
-            })
-        }
-
         var body struct {
             Data      string `json:"data"`
             EventID   string `json:"event_id"`
@@ -892,18 +1020,27 @@ func appHandleCertEditorUploadHtml(backend *Backend, route fiber.Router) {
             })
         }
 
-        var currentEventPart table.EventParticipant
-        res = backend.db.Where("user_id = ? AND event_id = ?", currentUser.ID, body.EventID).First(&currentEventPart)
-        if res.Error != nil && admin != 1 {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+        // NOTE: event_id end up on the file path, so it must be a real number.
+        eventID, err := strconv.Atoi(body.EventID)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to get the event participant with that user and event from the db, %v", res.Error),
-                "error_code": 10,
+                "message": fmt.Sprintf("Invalid event_id, %v", err),
+                "error_code": 4,
                 "data": nil,
             })
         }
 
-        if admin != 1 && currentEventPart.EventPRole != "committee" {
+        _, err = requireEventRole(backend, c, eventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to get the event participant with that user and event from the db, %v", err),
+                    "error_code": 10,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials for this function",
@@ -921,7 +1058,7 @@ func appHandleCertEditorUploadHtml(backend *Backend, route fiber.Router) {
 			})
 		}
 
-		certDir := "static"
+		certDir := backend.config.Upload.StaticDir
 		if err := os.MkdirAll(certDir, 0755); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
@@ -945,35 +1082,55 @@ func appHandleCertEditorUploadHtml(backend *Backend, route fiber.Router) {
 			})
 		}
 
-		certTempDir := fmt.Sprintf("%s/%s", certDir, body.EventID)
-		if err := os.MkdirAll(certTempDir, 0755); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"message": "Failed to create certificate template directory",
-				"error_code": 7,
-				"data": nil,
-			})
-		}
+        var certTemp table.CertTemplate
+        res := backend.db.Where("event_id = ?", eventID).Limit(1).Find(&certTemp)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch cert temp from db, %v", res.Error),
+                "error_code": 9,
+                "data": nil,
+            })
+        }
+        if res.RowsAffected == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "This event have no certificate template yet.",
+                "error_code": 11,
+                "data": nil,
+            })
+        }
 
-        htmlFilename := fmt.Sprintf("%s/index.html", certTempDir)
-		err = os.WriteFile(htmlFilename, decoded, 0644)
+        // NOTE: Checked before it become a version, see certPlaceholders for
+        //       what a template can use.
+        if errs := validateCertTemplate(backend, string(decoded)); errs != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid certificate template, %v", errs[0]),
+                "error_code": 12,
+                "data": errs,
+            })
+        }
+
+        // NOTE: Every save is a new version on its own folder, a bad one can
+        //       be rolled back with cert-template-activate.
+        version, err := saveCertTemplateVersion(backend, &certTemp, decoded, certTemplateBGSource(backend, &certTemp), &user.ID)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
-				"message": "Failed to save data.",
+				"message": fmt.Sprintf("Failed to save data, %v", err),
 				"error_code": 8,
 				"data": nil,
 			})
 		}
 
-        // NOTE: I think putting here is the best way.
-        backend.engine.ClearCache()
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "HTML Uploaded successfully.",
             "error_code": 0,
             "data": fiber.Map{
-                "filename": fmt.Sprintf("%s://%s/%s", backend.mode, backend.address, htmlFilename),
+                "filename": publicURL(backend, "static/"+version.CertVPath),
+                "version": version.CertVNumber,
             },
         })
     })
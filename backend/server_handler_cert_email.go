@@ -0,0 +1,115 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+// NOTE: Mail every attendee that did not get it yet, safe to call again, only
+//       the one still missing (eg. a late attendance) are mailed. attach send
+//       the pdf along the link. At most one batch per call, `remaining` say
+//       how many are still not mailed, revoked certificate included.
+// POST : api/protected/certificate-email
+func appHandleCertificateEmail(backend *Backend, route fiber.Router) {
+    route.Post("certificate-email", func (c *fiber.Ctx) error {
+        var body struct {
+            EventID int  `json:"event_id"`
+            Attach  bool `json:"attach"`
+        }
+
+        err := c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        _, err = requireEventRole(backend, c, body.EventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Only committee members and admins can send the certificate.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var event table.Event
+        res := backend.db.Where("id = ?", body.EventID).First(&event)
+        if res.Error != nil {
+            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Event not found.",
+                    "error_code": 4,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the event, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        if event.EventDEnd.After(time.Now()) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "The event is not done yet.",
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        if _, err := resolveCertTemplate(backend, event.ID); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "This event have no certificate template yet.",
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        sent, err := sendCertificateEmails(backend, &event, body.Attach)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to queue the certificate, %v", err),
+                "error_code": 7,
+                "data": nil,
+            })
+        }
+
+        var remaining int64
+        backend.db.Model(&table.EventParticipant{}).
+            Where("event_id = ? AND eventp_come = ? AND eventp_cert_sent_at IS NULL", event.ID, true).
+            Count(&remaining)
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Certificate queued.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "queued": sent,
+                "remaining": remaining,
+            },
+        })
+    })
+}
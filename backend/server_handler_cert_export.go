@@ -0,0 +1,130 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "log"
+    "strconv"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+// NOTE: format can be `pdf` (default) or `html`. The zip is streamed, so an
+//       error after the first byte only show up on the log and the manifest.
+// GET : api/protected/certificate-export
+func appHandleCertificateExport(backend *Backend, route fiber.Router) {
+    route.Get("certificate-export", func (c *fiber.Ctx) error {
+        eventId, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid event_id on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        format := c.Query("format", "pdf")
+        if format != "pdf" && format != "html" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid format, must be 'pdf' or 'html'.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        _, err = requireEventRole(backend, c, eventId, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
+                    "error_code": 3,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Only committee members and admins can export the certificate.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        var event table.Event
+        res := backend.db.Where("id = ?", eventId).First(&event)
+        if res.Error != nil {
+            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Event not found.",
+                    "error_code": 5,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the event, %v", res.Error),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        if event.EventDEnd.After(time.Now()) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "The event is not done yet.",
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        files, err := resolveCertTemplate(backend, eventId)
+        if err != nil {
+            if errors.Is(err, errCertTemplateMissing) || errors.Is(err, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "This event have no certificate template yet.",
+                    "error_code": 7,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch certificate template from the db, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var parts []table.EventParticipant
+        res = backend.db.Preload("User").Preload("Event").
+            Where("event_id = ? AND eventp_come = ?", eventId, true).
+            Order("id").
+            Find(&parts)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the participant, %v", res.Error),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        c.Set(fiber.HeaderContentType, "application/zip")
+        c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"certificate-event-%d.zip\"", eventId))
+        c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+            if err := writeCertificateZip(backend, w, files, parts, event.EventForm, format); err != nil {
+                log.Printf("WARN: Failed to export the certificate of event %d, %v", eventId, err)
+                return
+            }
+            w.Flush()
+        })
+        return nil
+    })
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: event_id filter to one event, status can be `active` or `revoked`,
+//       both are optional. Every revision is listed, the newest first.
+// GET : api/protected/certificate-list
+func appHandleCertificateList(backend *Backend, route fiber.Router) {
+    route.Get("certificate-list", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        offset, err := strconv.Atoi(c.Query("offset", "0"))
+        if err != nil {
+            offset = 0
+        }
+        limit, err := strconv.Atoi(c.Query("limit", "100"))
+        if err != nil {
+            limit = 100
+        }
+
+        query := backend.db.Model(&table.Certificate{})
+        if eventId, err := strconv.Atoi(c.Query("event_id")); err == nil {
+            query = query.Where("cert_participant_id IN (?)",
+                backend.db.Model(&table.EventParticipant{}).Unscoped().Select("id").Where("event_id = ?", eventId))
+        }
+        switch c.Query("status") {
+        case "active":
+            query = query.Where("cert_revoked_at IS NULL")
+        case "revoked":
+            query = query.Where("cert_revoked_at IS NOT NULL")
+        }
+
+        var certs []table.Certificate
+        res := query.Preload("Participant.User").Preload("Participant.Event").Preload("TemplateVersion").
+            Offset(offset).Limit(limit).
+            Order("cert_issued_at DESC").
+            Find(&certs)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the certificate from db, %v", res.Error),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": certs,
+        })
+    })
+}
+
+// NOTE: The reason is shown on the verification page, so it is required.
+// POST : api/protected/certificate-revoke
+func appHandleCertificateRevoke(backend *Backend, route fiber.Router) {
+    route.Post("certificate-revoke", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            ID     int    `json:"id"`
+            Reason string `json:"reason"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil || body.Reason == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, id and reason are required.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var cert table.Certificate
+        res := backend.db.Where("id = ?", body.ID).Limit(1).Find(&cert)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the certificate from db, %v", res.Error),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        if res.RowsAffected == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Certificate not found.",
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        err = revokeCertificate(backend, &cert, body.Reason)
+        if err != nil {
+            if errors.Is(err, errCertRevoked) {
+                return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The certificate is already revoked.",
+                    "error_code": 6,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to revoke the certificate, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Certificate revoked.",
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
+
+// NOTE: id is the id of any certificate of the participant, the current one
+//       is revoked (if not already) and a new serial is issued.
+// POST : api/protected/certificate-reissue
+func appHandleCertificateReissue(backend *Backend, route fiber.Router) {
+    route.Post("certificate-reissue", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            ID int `json:"id"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var cert table.Certificate
+        res := backend.db.Where("id = ?", body.ID).Limit(1).Find(&cert)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the certificate from db, %v", res.Error),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        if res.RowsAffected == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Certificate not found.",
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        newCert, err := reissueCertificate(backend, cert.CertParticipantId)
+        if err != nil {
+            if errors.Is(err, errCertNotIssued) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The participant is gone, did not come or the event is not done yet.",
+                    "error_code": 6,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to reissue the certificate, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Certificate reissued.",
+            "error_code": 0,
+            "data": newCert,
+        })
+    })
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+    "bytes"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+// GET : api/protected/cert-library
+func appHandleCertLibraryList(backend *Backend, route fiber.Router) {
+    route.Get("cert-library", func (c *fiber.Ctx) error {
+        _, err := currentUser(c)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        var libs []table.CertLibrary
+        res := backend.db.Preload("Author").Order("certl_name").Find(&libs)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template library from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        data := make([]fiber.Map, 0, len(libs))
+        for _, lib := range libs {
+            author := ""
+            if lib.Author != nil {
+                author = lib.Author.UserFullName
+            }
+            data = append(data, fiber.Map{
+                "id": lib.ID,
+                "name": lib.CertLName,
+                "desc": lib.CertLDesc,
+                "author": author,
+                "created_at": lib.CreatedAt,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": data,
+        })
+    })
+}
+
+// NOTE: data_html and data_img are base64 (a data url is fine), the image is
+//       optional and must be a png. The html point to it with `@@`, checked
+//       like an editor save.
+// POST : api/protected/cert-library-upload
+func appHandleCertLibraryUpload(backend *Backend, route fiber.Router) {
+    route.Post("cert-library-upload", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            Name     string `json:"name"`
+            Desc     string `json:"desc"`
+            DataHTML string `json:"data_html"`
+            DataIMG  string `json:"data_img"`
+        }
+
+        err = c.BodyParser(&body)
+        body.Name = strings.TrimSpace(body.Name)
+        if err != nil || body.Name == "" || len(body.Name) > 100 || body.DataHTML == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, name (at most 100 character) and data_html are required.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        decode := func(data string) ([]byte, error) {
+            if i := strings.Index(data, ","); i != -1 {
+                data = data[i+1:]
+            }
+            return base64.StdEncoding.DecodeString(data)
+        }
+
+        html, err := decode(body.DataHTML)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid base64 data_html, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        var img []byte
+        if body.DataIMG != "" {
+            img, err = decode(body.DataIMG)
+            if err != nil {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Invalid base64 data_img, %v", err),
+                    "error_code": 4,
+                    "data": nil,
+                })
+            }
+            if contentType := http.DetectContentType(img); contentType != "image/png" {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Invalid content type: %s", contentType),
+                    "error_code": 4,
+                    "data": nil,
+                })
+            }
+        }
+
+        if errs := validateCertTemplate(backend, string(html)); errs != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid certificate template, %v", errs[0]),
+                "error_code": 5,
+                "data": errs,
+            })
+        }
+
+        var count int64
+        res := backend.db.Model(&table.CertLibrary{}).Where("certl_name = ?", body.Name).Count(&count)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template library from db, %v", res.Error),
+                "error_code": 7,
+                "data": nil,
+            })
+        }
+        if count > 0 {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "A library template with that name already exist.",
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        lib := table.CertLibrary{
+            CertLName:     body.Name,
+            CertLDesc:     body.Desc,
+            CertLAuthorId: &user.ID,
+        }
+        err = createCertLibrary(backend, &lib, html, img)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to save the library template, %v", err),
+                "error_code": 7,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Library template uploaded.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "id": lib.ID,
+            },
+        })
+    })
+}
+
+// NOTE: Same as cert-template-preview but for a library template, format can
+//       be `html` (default) or `pdf`.
+// GET : api/protected/cert-library-preview
+func appHandleCertLibraryPreview(backend *Backend, route fiber.Router) {
+    route.Get("cert-library-preview", func (c *fiber.Ctx) error {
+        _, err := currentUser(c)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        id, err := strconv.Atoi(c.Query("id"))
+        format := c.Query("format", "html")
+        if err != nil || (format != "pdf" && format != "html") {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid id or format on query, format must be 'html' or 'pdf'.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        var lib table.CertLibrary
+        res := backend.db.Where("id = ?", id).First(&lib)
+        if res.Error != nil {
+            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Library template not found.",
+                    "error_code": 3,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template library from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        qr, err := certificateSampleQR(backend)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to make the qr code, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        files := certLibraryFiles(backend, &lib)
+        data := certificateSampleData(backend, nil, qr)
+
+        var buf bytes.Buffer
+        if format == "pdf" {
+            err = renderCertificatePDF(backend, files, data, qr, &buf)
+        } else {
+            err = renderCertLibraryHTML(backend, files, &buf, data)
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to render the template, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        if format == "pdf" {
+            c.Set(fiber.HeaderContentType, "application/pdf")
+            c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"library-%d.pdf\"", id))
+        } else {
+            c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+        }
+        return c.Send(buf.Bytes())
+    })
+}
+
+// POST : api/protected/cert-library-delete
+func appHandleCertLibraryDelete(backend *Backend, route fiber.Router) {
+    route.Post("cert-library-delete", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            ID int `json:"id"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var lib table.CertLibrary
+        res := backend.db.Where("id = ?", body.ID).Limit(1).Find(&lib)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template library from db, %v", res.Error),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        if res.RowsAffected == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Library template not found.",
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        err = deleteCertLibrary(backend, &lib)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to delete the library template, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Library template deleted.",
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
+
+// NOTE: Start the certificate of an event from a library template, it become
+//       a new version (the one before stay on the version list). The event
+//       get a CertTemplate row when it have none yet.
+// POST : api/protected/cert-library-use
+func appHandleCertLibraryUse(backend *Backend, route fiber.Router) {
+    route.Post("cert-library-use", func (c *fiber.Ctx) error {
+        var body struct {
+            ID      int `json:"id"`
+            EventID int `json:"event_id"`
+        }
+
+        err := c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        user, err := requireEventRole(backend, c, body.EventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Only committee members and admins can set the certificate template.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var lib table.CertLibrary
+        res := backend.db.Where("id = ?", body.ID).Limit(1).Find(&lib)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template library from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+        if res.RowsAffected == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Library template not found.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        certTemp, err := certTemplateOfEvent(backend, body.EventID)
+        if err != nil {
+            if errors.Is(err, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Event not found.",
+                    "error_code": 6,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch cert temp from db, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        version, err := useCertLibrary(backend, &lib, certTemp, &user.ID)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to copy the library template, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Library template copied to the event.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "version": version.CertVNumber,
+            },
+        })
+    })
+}
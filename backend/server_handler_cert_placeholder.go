@@ -0,0 +1,44 @@
+package main
+
+import (
+    "sort"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: What a certificate template can use, for the editor and whoever write
+//       the html by hand. Anything else is refused on upload.
+// GET : api/protected/cert-template-placeholders
+func appHandleCertTemplatePlaceholders(backend *Backend, route fiber.Router) {
+    route.Get("cert-template-placeholders", func (c *fiber.Ctx) error {
+        locales := make([]string, 0, len(certLocales))
+        for locale := range certLocales {
+            locales = append(locales, locale)
+        }
+        sort.Strings(locales)
+
+        // NOTE: A partial that fail to be read is just not listed, the upload
+        //       will tell why.
+        partials := []string{}
+        if sources, err := backend.engine.Partials(); err == nil {
+            for name := range sources {
+                partials = append(partials, name)
+            }
+        }
+        sort.Strings(partials)
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "placeholders": certPlaceholders,
+                "functions": certFunctions,
+                "builtins": certBuiltins,
+                "partials": partials,
+                "locales": locales,
+                "default_locale": backend.config.Locale,
+            },
+        })
+    })
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "strconv"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: The check shared by the version api, when the template is nil the
+//       error response is already written and err is what the handler should
+//       return.
+func certTemplateForCommittee(backend *Backend, c *fiber.Ctx, eventId int) (*table.CertTemplate, error) {
+    _, err := requireEventRole(backend, c, eventId, table.CommitteeU)
+    if err != nil {
+        if authzStatus(err) != fiber.StatusUnauthorized {
+            return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+        return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "success": false,
+            "message": "Only committee members and admins can manage the certificate template.",
+            "error_code": 3,
+            "data": nil,
+        })
+    }
+
+    var certTemp table.CertTemplate
+    res := backend.db.Preload("Event").Where("event_id = ?", eventId).Limit(1).Find(&certTemp)
+    if res.Error != nil {
+        return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": fmt.Sprintf("Failed to fetch cert temp from db, %v", res.Error),
+            "error_code": 2,
+            "data": nil,
+        })
+    }
+    if res.RowsAffected == 0 {
+        return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "success": false,
+            "message": "This event have no certificate template yet.",
+            "error_code": 4,
+            "data": nil,
+        })
+    }
+    return &certTemp, nil
+}
+
+// NOTE: Newest first, `active` mark the one the certificate use now. None is
+//       active when the path was set by hand with cert-edit.
+// GET : api/protected/cert-template-versions
+func appHandleCertTemplateVersions(backend *Backend, route fiber.Router) {
+    route.Get("cert-template-versions", func (c *fiber.Ctx) error {
+        eventId, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid event_id on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        certTemp, err := certTemplateForCommittee(backend, c, eventId)
+        if certTemp == nil {
+            return err
+        }
+
+        var versions []table.CertTemplateVersion
+        res := backend.db.Preload("Author").
+            Where("certv_template_id = ?", certTemp.ID).
+            Order("certv_number DESC").
+            Find(&versions)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template version from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        data := make([]fiber.Map, 0, len(versions))
+        for _, version := range versions {
+            author := ""
+            if version.Author != nil {
+                author = version.Author.UserFullName
+            }
+            data = append(data, fiber.Map{
+                "version": version.CertVNumber,
+                "hash": version.CertVHash,
+                "author": author,
+                "created_at": version.CreatedAt,
+                "active": certTemp.CertActiveVersion != nil && *certTemp.CertActiveVersion == version.ID,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": data,
+        })
+    })
+}
+
+// NOTE: Roll back (or forward) to any saved version, the file are still
+//       there so nothing is rewritten.
+// POST : api/protected/cert-template-activate
+func appHandleCertTemplateActivate(backend *Backend, route fiber.Router) {
+    route.Post("cert-template-activate", func (c *fiber.Ctx) error {
+        var body struct {
+            EventID int `json:"event_id"`
+            Version int `json:"version"`
+        }
+
+        err := c.BodyParser(&body)
+        if err != nil || body.Version <= 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, event_id and version are required.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        certTemp, err := certTemplateForCommittee(backend, c, body.EventID)
+        if certTemp == nil {
+            return err
+        }
+
+        version, err := findCertTemplateVersion(backend, certTemp, body.Version)
+        if err != nil {
+            if errors.Is(err, errCertVersionNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Template version not found.",
+                    "error_code": 5,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the template version from db, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        err = activateCertTemplateVersion(backend, certTemp, version)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to activate the template version, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Template version activated.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "version": version.CertVNumber,
+            },
+        })
+    })
+}
+
+// NOTE: Render a version with sample participant, version is optional (the
+//       active one) and format can be `html` (default) or `pdf`. Nothing is
+//       issued and the pdf is not cached.
+// GET : api/protected/cert-template-preview
+func appHandleCertTemplatePreview(backend *Backend, route fiber.Router) {
+    route.Get("cert-template-preview", func (c *fiber.Ctx) error {
+        eventId, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid event_id on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        number, err := strconv.Atoi(c.Query("version", "0"))
+        if err != nil || number < 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid version on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        format := c.Query("format", "html")
+        if format != "pdf" && format != "html" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid format, must be 'html' or 'pdf'.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        certTemp, err := certTemplateForCommittee(backend, c, eventId)
+        if certTemp == nil {
+            return err
+        }
+
+        path := certTemp.CertTemplate
+        if number != 0 || certTemp.CertActiveVersion != nil {
+            version, err := findCertTemplateVersion(backend, certTemp, number)
+            if err != nil {
+                if errors.Is(err, errCertVersionNotFound) {
+                    return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                        "success": false,
+                        "message": "Template version not found.",
+                        "error_code": 5,
+                        "data": nil,
+                    })
+                }
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to fetch the template version from db, %v", err),
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
+            path = version.CertVPath
+        }
+        files := newCertTemplateFiles(backend, *certTemp, path)
+
+        qr, err := certificateSampleQR(backend)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to make the qr code, %v", err),
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+        data := certificateSampleData(backend, &certTemp.Event, qr)
+
+        if format == "pdf" {
+            var buf bytes.Buffer
+            if err := renderCertificatePDF(backend, files, data, qr, &buf); err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to render the template, %v", err),
+                    "error_code": 6,
+                    "data": nil,
+                })
+            }
+            c.Set(fiber.HeaderContentType, "application/pdf")
+            c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"preview-%d.pdf\"", eventId))
+            return c.Send(buf.Bytes())
+        }
+
+        var buf bytes.Buffer
+        if err := backend.engine.Render(&buf, files.Name, data); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to render the template, %v", err),
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+        c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+        return c.Send(buf.Bytes())
+    })
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: status can be `pending`, `failed`, `sent` or `sending`,
+//       if not supplied it will return both pending and failed.
+// GET : api/protected/email-outbox-list
+func appHandleEmailOutboxList(backend *Backend, route fiber.Router) {
+    route.Get("email-outbox-list", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        offset, err := strconv.Atoi(c.Query("offset", "0"))
+        if err != nil {
+            offset = 0
+        }
+        limit, err := strconv.Atoi(c.Query("limit", "100"))
+        if err != nil {
+            limit = 100
+        }
+
+        statuses := []table.EmailStatusEnum{table.EmailPending, table.EmailFailed}
+        if status := c.Query("status"); status != "" {
+            statuses = []table.EmailStatusEnum{table.EmailStatusEnum(status)}
+        }
+
+        var entries []table.EmailOutbox
+        res := backend.db.Where("email_status IN ?", statuses).
+            Offset(offset).Limit(limit).
+            Order("created_at DESC").
+            Find(&entries)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the email outbox from db, %v", res.Error),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": entries,
+        })
+    })
+}
+
+// NOTE: Reset the attempts so the worker pick it up again right away. A
+//       sensitive one that failed have no body anymore, it cant come back.
+// POST : api/protected/email-outbox-requeue
+func appHandleEmailOutboxRequeue(backend *Backend, route fiber.Router) {
+    route.Post("email-outbox-requeue", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            IDs []int `json:"ids"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        if len(body.IDs) == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "No outbox id supplied.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        res := backend.db.Model(&table.EmailOutbox{}).
+            Where("id IN ? AND email_status IN ?", body.IDs, []table.EmailStatusEnum{table.EmailPending, table.EmailFailed}).
+            Where("email_sensitive = ? OR email_status = ?", false, table.EmailPending).
+            Updates(map[string]any{
+                "email_status": table.EmailPending,
+                "email_attempts": 0,
+                "email_next_try": time.Now(),
+            })
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to requeue the email, %v", res.Error),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        wakeEmailWorker(backend)
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Email requeued.",
+            "error_code": 0,
+            "data": res.RowsAffected,
+        })
+    })
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: Counter since the server start, a reload is a template that was
+//       cached but its file (or a partial) changed.
+// GET : api/protected/engine-stats
+func appHandleEngineStats(backend *Backend, route fiber.Router) {
+    route.Get("engine-stats", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": backend.engine.Stats(),
+        })
+    })
+}
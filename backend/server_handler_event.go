@@ -5,21 +5,24 @@ package main
 import (
 	"encoding/base64"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 	"webrpl/table"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // POST : api/protected/event-register
 func appHandleEventNew(backend *Backend, route fiber.Router) {
     route.Post("event-register", func (c *fiber.Ctx) error {
 
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleOrganizer)
+        if user == nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT token.",
@@ -28,9 +31,7 @@ func appHandleEventNew(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-
-        if isAdmin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials for this function",
@@ -49,6 +50,11 @@ func appHandleEventNew(backend *Backend, route fiber.Router) {
             Att           string    `json:"att"`
             Img           string    `json:"img"`
             Max           int       `json:"max"`
+            RegOpen       *time.Time `json:"reg_open"`
+            RegClose      *time.Time `json:"reg_close"`
+            CancelUntil   *time.Time `json:"cancel_until"`
+            Form          []table.EventFormField `json:"form"`
+            NeedApproval  bool      `json:"need_approval"`
         }
 
         err = c.BodyParser(&body)
@@ -101,6 +107,29 @@ func appHandleEventNew(backend *Backend, route fiber.Router) {
             EventImg: body.Img,
             EventMax: body.Max,
             EventLink: body.Link,
+            EventRegOpen: body.RegOpen,
+            EventRegClose: body.RegClose,
+            EventCancelUntil: body.CancelUntil,
+            EventForm: body.Form,
+            EventNeedApproval: body.NeedApproval,
+        }
+
+        if !validRegWindow(&newEvent) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid registration window, reg_open must be before reg_close and cancel_until before dend.",
+                "error_code": 9,
+                "data": nil,
+            })
+        }
+
+        if formErrs := validateEventForm(newEvent.EventForm); len(formErrs) > 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid registration form.",
+                "error_code": 10,
+                "data": formErrs,
+            })
         }
 
         if newEvent.EventDesc == "" || newEvent.EventName == "" || newEvent.EventSpeaker == "" {
@@ -112,11 +141,27 @@ func appHandleEventNew(backend *Backend, route fiber.Router) {
             })
         }
 
-        res = backend.db.Create(&newEvent)
-        if res.Error != nil {
+        // NOTE: Organizer is not admin, so make them committee of their own
+        //       event or they cant manage it after this.
+        err = backend.db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Create(&newEvent).Error; err != nil {
+                return err
+            }
+            if roleAtLeast(user.UserRole, table.RoleAdmin) {
+                return nil
+            }
+            return tx.Create(&table.EventParticipant{
+                EventId:    newEvent.ID,
+                UserId:     user.ID,
+                EventPRole: table.CommitteeU,
+                EventPCome: true,
+                EventPCode: newParticipantCode(),
+            }).Error
+        })
+        if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to create new event, %v", res.Error),
+                "message": fmt.Sprintf("Failed to create new event, %v", err),
                 "error_code": 6,
                 "data": nil,
             })
@@ -126,7 +171,9 @@ func appHandleEventNew(backend *Backend, route fiber.Router) {
             "success": true,
             "message": "Successfully added the event",
             "error_code": 0,
-            "data": nil,
+            "data": fiber.Map{
+                "id": newEvent.ID,
+            },
         })
     })
 }
@@ -136,7 +183,7 @@ func appHandleEventNew(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-info-all
 func appHandleEventInfoAll(backend *Backend, route fiber.Router) {
     route.Get("event-info-all", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -146,7 +193,7 @@ func appHandleEventInfoAll(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
         if email == "" {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
@@ -199,7 +246,7 @@ func appHandleEventInfoAll(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-info-of
 func appHandleEventInfoOf(backend *Backend, route fiber.Router) {
     route.Get("event-info-of", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -209,7 +256,7 @@ func appHandleEventInfoOf(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
         if email == "" {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
@@ -254,8 +301,8 @@ func appHandleEventInfoOf(backend *Backend, route fiber.Router) {
 // POST : api/protected/event-del
 func appHandleEventDel(backend *Backend, route fiber.Router) {
     route.Post("event-del", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT token.",
@@ -263,8 +310,7 @@ func appHandleEventDel(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to acces this api.",
@@ -306,7 +352,7 @@ func appHandleEventDel(backend *Backend, route fiber.Router) {
 // POST : api/protected/event-edit
 func appHandleEventEdit(backend *Backend, route fiber.Router) {
 	route.Post("event-edit", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -316,9 +362,6 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-		email := claims["email"].(string)
-
         var body struct {
 			EventId       int       `json:"id"`
             Desc          *string    `json:"desc"`
@@ -332,6 +375,14 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
             Max           *int       `json:"max"`
             EventMat      *int       `json:"event_mat_id"`
             CertTemplate  *int       `json:"cert_template_id"`
+            // NOTE: `null` clear them.
+            RegOpen       optionalTime `json:"reg_open"`
+            RegClose      optionalTime `json:"reg_close"`
+            CancelUntil   optionalTime `json:"cancel_until"`
+            // NOTE: `[]` remove the form, the answers already given are kept.
+            Form          *[]table.EventFormField `json:"form"`
+            // NOTE: Turning it off dont approve who is already pending.
+            NeedApproval  *bool     `json:"need_approval"`
         }
 
 		err = c.BodyParser(&body)
@@ -355,36 +406,22 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
 			})
 		}
 
-        if isAdmin != 1 {
-			var selUser table.User
-			res := backend.db.Where("user_email = ?", email).First(&selUser)
-			if res.Error != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"success": false,
-					"message": "Failed to fetch the event participant of this user.",
-					"error_code": 8,
-					"data": nil,
-				})
-			}
-
-			var evPart table.EventParticipant
-			res = backend.db.Where("event_id = ? AND user_id = ?", body.EventId, selUser.ID).First(&evPart)
-			if res.Error != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"success": false,
-					"message": "Failed to fetch the event participant of this user.",
-					"error_code": 9,
-					"data": nil,
-				})
-			}
-			if evPart.EventPRole != "committee" {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"success": false,
-					"message": "Invalid credentials for this function",
-					"error_code": 2,
-					"data": nil,
-				})
-			}
+        _, err = requireEventRole(backend, c, body.EventId, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Failed to fetch the event participant of this user.",
+                    "error_code": 9,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials for this function",
+                "error_code": 2,
+                "data": nil,
+            })
         }
 
 		if body.Desc != nil {
@@ -414,6 +451,21 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
 		if body.Max != nil {
 			event.EventMax = *body.Max
 		}
+        if body.RegOpen.Set {
+            event.EventRegOpen = body.RegOpen.Time
+        }
+        if body.RegClose.Set {
+            event.EventRegClose = body.RegClose.Time
+        }
+        if body.CancelUntil.Set {
+            event.EventCancelUntil = body.CancelUntil.Time
+        }
+        if body.Form != nil {
+            event.EventForm = *body.Form
+        }
+        if body.NeedApproval != nil {
+            event.EventNeedApproval = *body.NeedApproval
+        }
         if body.CertTemplate != nil {
             var cert_temp table.CertTemplate
             res := backend.db.Where("id = ?", *body.CertTemplate).First(&cert_temp)
@@ -443,8 +495,10 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
             event.EventMaterials = append(event.EventMaterials, mat)
         }
 
+        // NOTE: Only checked when the date is changed, it used to deref the
+        //       body even when the date was not sent.
         now := time.Now()
-        if body.DStart.Before(now) || body.DEnd.Before(*body.DStart) {
+        if (body.DStart != nil || body.DEnd != nil) && (event.EventDStart.Before(now) || event.EventDEnd.Before(event.EventDStart)) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"success": false,
 				"message": "Failed to edit event because invalid date.",
@@ -453,6 +507,24 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
 			})
         }
 
+        if !validRegWindow(&event) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid registration window, reg_open must be before reg_close and cancel_until before dend.",
+                "error_code": 10,
+                "data": nil,
+            })
+        }
+
+        if formErrs := validateEventForm(event.EventForm); len(formErrs) > 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid registration form.",
+                "error_code": 11,
+                "data": formErrs,
+            })
+        }
+
 		result = backend.db.Save(&event)
 		if result.Error != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -463,6 +535,13 @@ func appHandleEventEdit(backend *Backend, route fiber.Router) {
 			})
 		}
 
+        // NOTE: A bigger EventMax open seat for the waitlist.
+        if body.Max != nil {
+            if _, err := promoteWaitlist(backend, event.ID); err != nil {
+                log.Printf("WARN: Failed to promote the waitlist of event %d, %v", event.ID, err)
+            }
+        }
+
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "Event edited successfully.",
@@ -479,8 +558,8 @@ func appHandleEventUploadImage(backend *Backend, route fiber.Router) {
             Data    string `json:"data"`
         }
 
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleOrganizer)
+        if user == nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT token.",
@@ -489,9 +568,7 @@ func appHandleEventUploadImage(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials for this function",
@@ -519,7 +596,7 @@ func appHandleEventUploadImage(backend *Backend, route fiber.Router) {
             })
         }
 
-        imgDir := "static"
+        imgDir := backend.config.Upload.StaticDir
         if err := os.MkdirAll(imgDir, 0755); err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -556,7 +633,8 @@ func appHandleEventUploadImage(backend *Backend, route fiber.Router) {
             fileExt = ".webp"
         }
 
-        filename := fmt.Sprintf("%s/%d%s", imgDir, time.Now().Unix(), fileExt)
+        name := fmt.Sprintf("%d%s", time.Now().Unix(), fileExt)
+        filename := filepath.Join(imgDir, name)
 
         err = os.WriteFile(filename, imageData, 0644)
         if err != nil {
@@ -573,7 +651,7 @@ func appHandleEventUploadImage(backend *Backend, route fiber.Router) {
             "message": "Image uploaded successfully",
             "error_code": 0,
             "data": fiber.Map{
-                "filename": fmt.Sprintf("%s://%s/%s", backend.mode, backend.address, filename),
+                "filename": publicURL(backend, "static/"+name),
             },
         })
     })
@@ -582,7 +660,7 @@ func appHandleEventUploadImage(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-count
 func appHandleEventCount(backend *Backend, route fiber.Router) {
     route.Get("event-count", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -592,7 +670,7 @@ func appHandleEventCount(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
         if email == "" {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
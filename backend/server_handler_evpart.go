@@ -3,6 +3,7 @@ package main
 import (
     "strconv"
     "fmt"
+    "time"
     "webrpl/table"
     "errors"
 
@@ -16,7 +17,7 @@ import (
 // POST : api/protected/event-participate-register
 func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
     route.Post("event-participate-register", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -25,8 +26,7 @@ func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         if email == "" {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -41,6 +41,7 @@ func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
             EventId         int     `json:"id"`
             Role            string  `json:"role"`
             CustomUserEmail *string `json:"email"`
+            Answers         map[string]any `json:"answers"`
         }
 
         err = c.BodyParser(&body)
@@ -53,7 +54,19 @@ func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
             })
         }
 
-        if admin != 1 && body.Role == "committee" {
+        // NOTE: Adding committee or other user need committee right on the event.
+        _, err = requireEventRole(backend, c, body.EventId, table.CommitteeU)
+        canManage := err == nil
+        if err != nil && authzStatus(err) != fiber.StatusUnauthorized {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to check the event role, %v", err),
+                "error_code": 13,
+                "data": nil,
+            })
+        }
+
+        if !canManage && body.Role == "committee" {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid Credentials.",
@@ -82,28 +95,44 @@ func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
             })
         }
 
-        var eventParticipantCount int64
-        res = backend.db.Model(&table.EventParticipant{}).Where("event_id = ?", body.EventId).Count(&eventParticipantCount)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": "Failed to fetch event count from db.",
-                "error_code": 7,
-                "data": nil,
-            })
+        // NOTE: The committee can still add someone outside the window.
+        if !canManage {
+            switch checkRegWindow(&event, time.Now()) {
+            case errRegNotOpen:
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Registration for this event is not open yet.",
+                    "error_code": 14,
+                    "data": fiber.Map{
+                        "reg_open": event.EventRegOpen,
+                    },
+                })
+            case errRegClosed:
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Registration for this event is closed.",
+                    "error_code": 15,
+                    "data": fiber.Map{
+                        "reg_close": event.EventRegClose,
+                    },
+                })
+            }
         }
 
-        if event.EventMax <= int(eventParticipantCount) + 1 {
+        // NOTE: The committee adding someone may not know every answer, but
+        //       what it give is still checked.
+        answers, formErrs := validateFormAnswers(event.EventForm, body.Answers, !canManage)
+        if len(formErrs) > 0 {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Event is already full.",
-                "error_code": 8,
-                "data": nil,
+                "message": "Invalid answers for the registration form.",
+                "error_code": 16,
+                "data": formErrs,
             })
         }
 
         useThisEmail := email
-        if admin == 1 && body.CustomUserEmail != nil && *body.CustomUserEmail != "" {
+        if canManage && body.CustomUserEmail != nil && *body.CustomUserEmail != "" {
             useThisEmail = *body.CustomUserEmail
         }
 
@@ -118,58 +147,59 @@ func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
             })
         }
 
-        var exists bool
-        err = backend.db.Model(&table.EventParticipant{}).
-        Select("count(*) > 0").
-        Where("user_id = ? AND event_id = ?", currentUser.ID, body.EventId).
-        Find(&exists).
-        Error
+        // NOTE: The check and the insert are one transaction on the locked
+        //       event, two request at once can not both take the last seat.
+        //       Someone added by the committee is already approved.
+        pending := event.EventNeedApproval && !canManage
+        part, err := registerParticipant(backend, body.EventId, currentUser.ID, table.UserEventRoleEnum(body.Role), answers, pending)
         if err != nil {
+            if errors.Is(err, errAlreadyRegistered) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Failed to register this user again.",
+                    "error_code": 12,
+                    "data": nil,
+                })
+            }
+            if errors.Is(err, errRegRejected) {
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Your registration for this event was rejected.",
+                    "error_code": 17,
+                    "data": nil,
+                })
+            }
+            if errors.Is(err, errEventFull) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Event is already full.",
+                    "error_code": 8,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Event participant with the event_id : %d, and user_id : %d doesnt exist, %v", body.EventId, currentUser.ID, res.Error),
-                "error_code": 11,
-                "data": nil,
-            })
-        }
-        if exists {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-                "success": false,
-                "message": "Failed to register this user again.",
-                "error_code": 12,
-                "data": nil,
-            })
-        }
-
-        var Absence = false
-        if body.Role == "committee" {
-            Absence = true
-        }
-        random_strings := RandStringBytes(backend, fmt.Sprintf("%s-%d-%d-%d", currentUser.UserEmail, body.EventId, backend.rand.Int(), backend.rand.Int()))
-
-        NewEventParticipate := table.EventParticipant{
-            EventId: body.EventId,
-            UserId: currentUser.ID,
-            EventPRole: table.UserEventRoleEnum(body.Role),
-            EventPCome: Absence,
-            EventPCode: random_strings,
-        }
-
-        res = backend.db.Create(&NewEventParticipate)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": fmt.Sprintf("Failed to create new event participant, %v", res.Error),
+                "message": fmt.Sprintf("Failed to create new event participant, %v", err),
                 "error_code": 10,
                 "data": nil,
             })
         }
 
+        message := "New Event EventParticipant created."
+        switch part.EventPStatus {
+        case table.WaitlistedP:
+            message = "Event is full, added to the waitlist."
+        case table.PendingP:
+            message = "Registration is waiting for the committee approval."
+        }
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
-            "message": "New Event EventParticipant created.",
+            "message": message,
             "error_code": 0,
-            "data": nil,
+            "data": fiber.Map{
+                "status": part.EventPStatus,
+                "position": part.EventPWaitPos,
+            },
         })
     })
 }
@@ -177,7 +207,7 @@ func appHandleEventParticipateRegister(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-participate-info-of
 func appHandleEventParticipateInfoOf(backend *Backend, route fiber.Router) {
     route.Get("event-participate-info-of", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -186,8 +216,7 @@ func appHandleEventParticipateInfoOf(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        email := claims["email"].(string)
-        admin := claims["admin"].(float64)
+        email := user.UserEmail
 
         if email == "" {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -219,7 +248,16 @@ func appHandleEventParticipateInfoOf(backend *Backend, route fiber.Router) {
         }
 
         useThisEmail := email
-        if admin == 1 && emailQuery != "" {
+        if emailQuery != "" && emailQuery != email {
+            _, err = requireEventRole(backend, c, idQueryInt, table.CommitteeU)
+            if err != nil {
+                return c.Status(authzStatus(err)).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Invalid credentials to see other user participation.",
+                    "error_code": 6,
+                    "data": nil,
+                })
+            }
             useThisEmail = emailQuery
         }
 
@@ -249,6 +287,11 @@ func appHandleEventParticipateInfoOf(backend *Backend, route fiber.Router) {
             })
         }
 
+        // NOTE: The code is only given once approved, a new one is made then.
+        if evPart.EventPStatus == table.PendingP || evPart.EventPStatus == table.RejectedP {
+            evPart.EventPCode = ""
+        }
+
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "Check data.",
@@ -262,7 +305,7 @@ func appHandleEventParticipateInfoOf(backend *Backend, route fiber.Router) {
 // POST : api/protected/event-participate-del
 func appHandleEventParticipateDel(backend *Backend, route fiber.Router) {
     route.Post("event-participate-del", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -272,8 +315,6 @@ func appHandleEventParticipateDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-
         var body struct {
             EventID    int    `json:"event_id"`
             UserEmail  string `json:"email"`
@@ -311,8 +352,11 @@ func appHandleEventParticipateDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        if isAdmin != 1 && selEvPart.EventPRole != "committee" {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+        // NOTE: It used to check the role of the deleted participant instead
+        //       of the one who ask, so anyone could delete a committee.
+        _, err = requireEventRole(backend, c, body.EventID, table.CommitteeU)
+        if err != nil {
+            return c.Status(authzStatus(err)).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials for this function",
                 "error_code": 2,
@@ -320,11 +364,12 @@ func appHandleEventParticipateDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        res = backend.db.Delete(&table.EventParticipant{}, &selEvPart)
-        if res.Error != nil {
+        // NOTE: The seat is given to the next on the waitlist.
+        promoted, err := removeParticipant(backend, &selEvPart)
+        if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to delete the event participant with that id from the db, %v", res.Error),
+                "message": fmt.Sprintf("Failed to delete the event participant with that id from the db, %v", err),
                 "error_code": 5,
                 "data": nil,
             })
@@ -334,7 +379,99 @@ func appHandleEventParticipateDel(backend *Backend, route fiber.Router) {
             "success": true,
             "message": "event participant deleted.",
             "error_code": 0,
-            "data": nil,
+            "data": fiber.Map{
+                "promoted": promoted,
+            },
+        })
+    })
+}
+
+// NOTE: The user cancel their own registration (or leave the waitlist, or
+//       withdraw a pending one), only before the cancellation deadline and
+//       not after they came. A rejected one stay rejected.
+// POST : api/protected/event-participate-cancel
+func appHandleEventParticipateCancel(backend *Backend, route fiber.Router) {
+    route.Post("event-participate-cancel", func (c *fiber.Ctx) error {
+        user, err := currentUser(c)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            EventID int `json:"event_id"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var part table.EventParticipant
+        res := backend.db.Preload("Event").
+            Where("event_id = ? AND user_id = ? AND eventp_role = ? AND eventp_status <> ?", body.EventID, user.ID, table.NormalU, table.RejectedP).
+            First(&part)
+        if res.Error != nil {
+            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "You are not registered on this event.",
+                    "error_code": 3,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the event participant from the db, %v", res.Error),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        if part.EventPCome {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Attendance is already recorded, the registration can not be cancelled.",
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        if err := checkCancelDeadline(&part.Event, time.Now()); err != nil {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "The cancellation deadline of this event has passed.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        promoted, err := removeParticipant(backend, &part)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to cancel the registration, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Registration cancelled.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "promoted": promoted,
+            },
         })
     })
 }
@@ -343,7 +480,7 @@ func appHandleEventParticipateDel(backend *Backend, route fiber.Router) {
 // POST : api/protected/event-participate-edit
 func appHandleEventParticipateEdit(backend *Backend, route fiber.Router) {
     route.Post("event-participate-edit", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -353,8 +490,7 @@ func appHandleEventParticipateEdit(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        currentUserEmail := claims["email"].(string)
+        currentUserEmail := user.UserEmail
 
         var body struct {
             EventID    int     `json:"event_id"`
@@ -389,45 +525,32 @@ func appHandleEventParticipateEdit(backend *Backend, route fiber.Router) {
         }
 
         // Check authorization: Only admins or committee members can edit roles
-        if admin != 1 {
-            // Check if current user is committee for this event
-            var currentEventParticipant table.EventParticipant
-            res := backend.db.Where("user_email = ? AND event_id = ?", currentUserEmail, body.EventID).First(&currentEventParticipant)
-            if res.Error != nil {
-                if errors.Is(res.Error, gorm.ErrRecordNotFound) {
-                    return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-                        "success": false,
-                        "message": "You are not a participant of this event.",
-                        "error_code": 4,
-                        "data": nil,
-                    })
-                }
+        _, err = requireEventRole(backend, c, body.EventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
                 return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                     "success": false,
-                    "message": fmt.Sprintf("Failed to fetch current user participation, %v", res.Error),
+                    "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
                     "error_code": 3,
                     "data": nil,
                 })
             }
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Only committee members and admins can edit participant roles.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
 
-            if currentEventParticipant.EventPRole != "committee" {
-                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-                    "success": false,
-                    "message": "Only committee members and admins can edit participant roles.",
-                    "error_code": 4,
-                    "data": nil,
-                })
-            }
-
-            // Prevent committee members from editing their own role (security measure)
-            if targetUserEmail == currentUserEmail {
-                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-                    "success": false,
-                    "message": "Committee members cannot modify their own role.",
-                    "error_code": 7,
-                    "data": nil,
-                })
-            }
+        // Prevent committee members from editing their own role (security measure)
+        if !roleAtLeast(user.UserRole, table.RoleAdmin) && targetUserEmail == currentUserEmail {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Committee members cannot modify their own role.",
+                "error_code": 7,
+                "data": nil,
+            })
         }
 
         // Find target user
@@ -500,7 +623,7 @@ func appHandleEventParticipateEdit(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-participate-of-event
 func appHandleEventParticipateOfEvent(backend *Backend, route fiber.Router) {
     route.Get("event-participate-of-event", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -510,23 +633,23 @@ func appHandleEventParticipateOfEvent(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        if admin != 1 {
+        queryEventID := c.Query("event_id")
+        queryEventIDInt, err := strconv.Atoi(queryEventID)
+        if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Invalid Credentials.",
-                "error_code": 2,
+                "message": "event_id need to be integer.",
+                "error_code": 3,
                 "data": nil,
             })
         }
 
-        queryEventID := c.Query("event_id")
-        queryEventIDInt, err := strconv.Atoi(queryEventID)
+        _, err = requireEventRole(backend, c, queryEventIDInt, table.CommitteeU)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "event_id need to be integer.",
-                "error_code": 3,
+                "message": "Invalid Credentials.",
+                "error_code": 2,
                 "data": nil,
             })
         }
@@ -566,7 +689,7 @@ func appHandleEventParticipateOfEvent(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-participate-of-user
 func appHandleEventParticipateOfUser(backend *Backend, route fiber.Router) {
     route.Get("event-participate-of-user", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -576,13 +699,12 @@ func appHandleEventParticipateOfUser(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         userEmail := c.Query("email")
 
         useThisEmail := email
-        if admin == 1 && userEmail != "" {
+        if roleAtLeast(user.UserRole, table.RoleAdmin) && userEmail != "" {
             useThisEmail = userEmail
         }
 
@@ -599,9 +721,12 @@ func appHandleEventParticipateOfUser(backend *Backend, route fiber.Router) {
 
         // NOTE: Just display the one that registered as normal only for now...
         // If there is an issue it can fetch everything instead.
+        // The waitlisted, pending and rejected one have no seat (yet), they
+        // are left out.
         var eventList[] table.Event
         res = backend.db.Model(&table.EventParticipant{}).Joins("JOIN events ON events.id = event_participants.event_id").
         Where("event_participants.user_id = ? AND event_participants.eventp_role = ?", selectedUser.ID, "normal").
+        Where("event_participants.eventp_status = ?", table.RegisteredP).
         Select("events.*").Find(&eventList)
         if res.Error != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -623,7 +748,7 @@ func appHandleEventParticipateOfUser(backend *Backend, route fiber.Router) {
 // POST : api/protected/event-participate-absence-itself
 func appHandleEventParticipateAbsenceItself(backend *Backend, route fiber.Router) {
     route.Post("event-participate-absence-itself", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -633,7 +758,7 @@ func appHandleEventParticipateAbsenceItself(backend *Backend, route fiber.Router
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         var body struct {
             EventID int `json:"event_id"`
@@ -688,7 +813,8 @@ func appHandleEventParticipateAbsenceItself(backend *Backend, route fiber.Router
             })
         }
 
-        res = backend.db.Model(&table.EventParticipant{}).Where("event_id = ? AND eventp_role = ? AND user_id = ?", body.EventID, "normal", currentUser.ID).Update("eventp_come", true)
+        res = backend.db.Model(&table.EventParticipant{}).Where("event_id = ? AND eventp_role = ? AND eventp_status = ? AND user_id = ?", body.EventID, "normal", table.RegisteredP, currentUser.ID).Update("eventp_come", true)
+        issueCertificatesOf(backend, body.EventID)
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
@@ -702,7 +828,7 @@ func appHandleEventParticipateAbsenceItself(backend *Backend, route fiber.Router
 // POST : api/protected/event-participate-absence-bulk
 func appHandleEventParticipateAbsenceBulk(backend *Backend, route fiber.Router) {
     route.Post("event-participate-absence-bulk", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -712,9 +838,6 @@ func appHandleEventParticipateAbsenceBulk(backend *Backend, route fiber.Router)
             })
         }
 
-        email := claims["email"].(string)
-        admin := claims["admin"].(float64)
-
         var body struct {
             EventID int `json:"event_id"`
         }
@@ -729,55 +852,25 @@ func appHandleEventParticipateAbsenceBulk(backend *Backend, route fiber.Router)
             })
         }
 
-        var currentUser table.User
-        res := backend.db.Where("user_email = ?", email).First(&currentUser)
-        if res.Error != nil {
-            if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
-                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        _, err = requireEventRole(backend, c, body.EventID, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                     "success": false,
-                    "message": "Invalid user on JWT.",
-                    "error_code": 3,
+                    "message": fmt.Sprintf("Something wrong happened on the backend, %v", err),
+                    "error_code": 6,
                     "data": nil,
                 })
             }
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Something wrong happened on the backend, %v", res.Error),
-                "error_code": 4,
+                "message": "Invalid credentials for this API.",
+                "error_code": 7,
                 "data": nil,
             })
         }
 
-        if admin != 1 {
-            var eventPart table.EventParticipant
-            res = backend.db.Where("user_id = ? AND event_id = ?", currentUser.ID, body.EventID).First(&eventPart)
-            if res.Error != nil {
-                if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
-                    return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-                        "success": false,
-                        "message": "Invalid event participant.",
-                        "error_code": 5,
-                        "data": nil,
-                    })
-                }
-                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                    "success": false,
-                    "message": fmt.Sprintf("Something wrong happened on the backend, %v", res.Error),
-                    "error_code": 6,
-                    "data": nil,
-                })
-            }
-            if eventPart.EventPRole != "committee" {
-                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-                    "success": false,
-                    "message": "Invalid credentials for this API.",
-                    "error_code": 7,
-                    "data": nil,
-                })
-            }
-        }
-
-        res = backend.db.Model(&table.EventParticipant{}).Where("event_id = ? AND eventp_role = ?", body.EventID, "normal").Update("eventp_come", true)
+        res := backend.db.Model(&table.EventParticipant{}).Where("event_id = ? AND eventp_role = ? AND eventp_status = ?", body.EventID, "normal", table.RegisteredP).Update("eventp_come", true)
         if res.Error != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -786,6 +879,7 @@ func appHandleEventParticipateAbsenceBulk(backend *Backend, route fiber.Router)
                 "data": nil,
             })
         }
+        issueCertificatesOf(backend, body.EventID)
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
@@ -799,7 +893,7 @@ func appHandleEventParticipateAbsenceBulk(backend *Backend, route fiber.Router)
 // POST : api/protected/event-participate-absence
 func appHandleEventParticipateAbsence(backend *Backend, route fiber.Router) {
     route.Post("event-participate-absence", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -808,8 +902,6 @@ func appHandleEventParticipateAbsence(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        email := claims["email"].(string)
-        admin := claims["admin"].(float64)
 
         var body struct  {
             EventId   int    `json:"id"`
@@ -826,40 +918,29 @@ func appHandleEventParticipateAbsence(backend *Backend, route fiber.Router) {
             })
         }
 
-        var userSender table.User
-        res := backend.db.Where("user_email = ?", email).First(&userSender)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": fmt.Sprintf("Failed to fetch user from the db, %v", res.Error),
-                "error_code": 2,
-                "data": nil,
-            })
-        }
-
-        var userEventPart table.EventParticipant
-        res = backend.db.Where("user_id = ? AND event_id = ?", userSender.ID, body.EventId).First(&userEventPart)
-        if res.Error != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": fmt.Sprintf("Failed to fetch event participant from the db, %v", res.Error),
-                "error_code": 3,
-                "data": nil,
-            })
-        }
-
         // Check if the requestee is a committee
-        if userEventPart.EventPRole != "committee" && admin != 1 {
+        _, err = requireEventRole(backend, c, body.EventId, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to fetch event participant from the db, %v", err),
+                    "error_code": 3,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Invalid credentials for this function. DEBUG: %s, %f", userEventPart.EventPRole, admin),
+                "message": "Invalid credentials for this function.",
                 "error_code": 4,
                 "data": nil,
             })
         }
 
+        // NOTE: Scoped to the event, committee of one event cant mark the other.
+        //       A waitlisted code is not a seat, so it is invalid too.
         var absenTarget table.EventParticipant
-        res = backend.db.Where("eventp_code = ?", body.Secret).First(&absenTarget)
+        res := backend.db.Where("eventp_code = ? AND event_id = ? AND eventp_status = ?", body.Secret, body.EventId, table.RegisteredP).First(&absenTarget)
         if res.Error != nil {
             if errors.Is(res.Error, gorm.ErrRecordNotFound) {
                 return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -887,6 +968,7 @@ func appHandleEventParticipateAbsence(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
+        issueCertificatesOf(backend, body.EventId)
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
@@ -900,7 +982,7 @@ func appHandleEventParticipateAbsence(backend *Backend, route fiber.Router) {
 // GET : api/protected/event-participate-of-event-count
 func appHandleEventParticipateOfEventCount(backend *Backend, route fiber.Router) {
     route.Get("event-participate-of-event-count", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -909,30 +991,30 @@ func appHandleEventParticipateOfEventCount(backend *Backend, route fiber.Router)
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
 
-        if admin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+        queryEventID, err := strconv.Atoi(c.Query("id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Invalid credentials to acces this api.",
-                "error_code": 2,
+                "message": fmt.Sprintf("Invalid Query : %v", err),
+                "error_code": 3,
                 "data": nil,
             })
         }
 
-        queryEventID := c.Query("id")
-        if queryEventID == "" {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        _, err = requireEventRole(backend, c, queryEventID, table.CommitteeU)
+        if err != nil {
+            return c.Status(authzStatus(err)).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Invalid Query : %v", err),
-                "error_code": 3,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
                 "data": nil,
             })
         }
 
-        var eventParticipantCount int64
-        res := backend.db.Model(&table.EventParticipant{}).Where("event_id = ?", queryEventID).Count(&eventParticipantCount)
-        if res.Error != nil {
+        // NOTE: The waitlist is not counted, see event-participate-waitlist.
+        eventParticipantCount, err := countRegistered(backend.db, queryEventID)
+        if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Failed to fetch event count from db.",
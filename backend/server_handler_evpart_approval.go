@@ -0,0 +1,212 @@
+package main
+
+import (
+    "fmt"
+    "strconv"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// Body of event-participate-approve and event-participate-reject.
+type approvalBody struct {
+    EventID int      `json:"event_id"`
+    Emails  []string `json:"emails"`
+    // NOTE: Only sent on the rejection mail.
+    Reason  string   `json:"reason"`
+}
+
+// NOTE: Every email must be one of the given status, nothing is done when
+//       one is not so a typo dont approve half of the list. Nil mean the
+//       error response is already written.
+func approvalParticipants(backend *Backend, c *fiber.Ctx, body *approvalBody, statuses []table.ParticipantStatusEnum) ([]table.EventParticipant, error) {
+    var parts []table.EventParticipant
+    res := backend.db.Preload("User").
+        Joins("JOIN users ON users.id = event_participants.user_id").
+        Where("event_participants.event_id = ? AND event_participants.eventp_status IN ? AND users.user_email IN ?", body.EventID, statuses, body.Emails).
+        Find(&parts)
+    if res.Error != nil {
+        return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": fmt.Sprintf("Failed to fetch the participant from db, %v", res.Error),
+            "error_code": 2,
+            "data": nil,
+        })
+    }
+
+    byEmail := map[string]table.EventParticipant{}
+    for _, part := range parts {
+        byEmail[part.User.UserEmail] = part
+    }
+    ordered := make([]table.EventParticipant, 0, len(body.Emails))
+    seen := map[string]bool{}
+    for _, email := range body.Emails {
+        part, ok := byEmail[email]
+        if !ok {
+            return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("%s is not waiting for approval on this event.", email),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        if !seen[email] {
+            seen[email] = true
+            ordered = append(ordered, part)
+        }
+    }
+    return ordered, nil
+}
+
+// NOTE: In the order they registered.
+// GET : api/protected/event-participate-pending
+func appHandleEventParticipatePending(backend *Backend, route fiber.Router) {
+    route.Get("event-participate-pending", func (c *fiber.Ctx) error {
+        eventId, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid event_id on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if ok, err := eventCommitteeCheck(backend, c, eventId, "see the pending registration"); !ok {
+            return err
+        }
+
+        var pending []table.EventParticipant
+        res := backend.db.Preload("User").
+            Where("event_id = ? AND eventp_status = ?", eventId, table.PendingP).
+            Order("created_at ASC, id ASC").
+            Find(&pending)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the pending registration from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": pending,
+        })
+    })
+}
+
+// NOTE: Approved in the order of `emails`, once the event is full the rest
+//       go to the waitlist.
+// POST : api/protected/event-participate-approve
+func appHandleEventParticipateApprove(backend *Backend, route fiber.Router) {
+    route.Post("event-participate-approve", func (c *fiber.Ctx) error {
+        var body approvalBody
+
+        err := c.BodyParser(&body)
+        if err != nil || len(body.Emails) == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, event_id and emails are required.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if ok, err := eventCommitteeCheck(backend, c, body.EventID, "approve a registration"); !ok {
+            return err
+        }
+
+        parts, err := approvalParticipants(backend, c, &body, []table.ParticipantStatusEnum{table.PendingP, table.RejectedP})
+        if parts == nil {
+            return err
+        }
+
+        approved, err := approveParticipants(backend, body.EventID, parts)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to approve the registration, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        registered, waitlisted := 0, 0
+        for _, part := range approved {
+            if part.EventPStatus == table.WaitlistedP {
+                waitlisted++
+            } else {
+                registered++
+            }
+        }
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Registration approved.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "registered": registered,
+                "waitlisted": waitlisted,
+            },
+        })
+    })
+}
+
+// POST : api/protected/event-participate-reject
+func appHandleEventParticipateReject(backend *Backend, route fiber.Router) {
+    route.Post("event-participate-reject", func (c *fiber.Ctx) error {
+        var body approvalBody
+
+        err := c.BodyParser(&body)
+        if err != nil || len(body.Emails) == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, event_id and emails are required.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if ok, err := eventCommitteeCheck(backend, c, body.EventID, "reject a registration"); !ok {
+            return err
+        }
+
+        var event table.Event
+        res := backend.db.Where("id = ?", body.EventID).First(&event)
+        if res.Error != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Event not found.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        parts, err := approvalParticipants(backend, c, &body, []table.ParticipantStatusEnum{table.PendingP})
+        if parts == nil {
+            return err
+        }
+
+        rejected, err := rejectParticipants(backend, &event, parts, body.Reason)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to reject the registration, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Registration rejected.",
+            "error_code": 0,
+            "data": fiber.Map{
+                "rejected": len(rejected),
+            },
+        })
+    })
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+    "bytes"
+    "encoding/csv"
+    "fmt"
+    "strconv"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: Every participant of the event (waitlist too) as a csv, with one
+//       column per field of the registration form after the fixed one.
+// GET : api/protected/event-participate-export
+func appHandleEventParticipateExport(backend *Backend, route fiber.Router) {
+    route.Get("event-participate-export", func (c *fiber.Ctx) error {
+        eventId, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid event_id on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        _, err = requireEventRole(backend, c, eventId, table.CommitteeU)
+        if err != nil {
+            if authzStatus(err) != fiber.StatusUnauthorized {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Only committee members and admins can export the participant.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var event table.Event
+        res := backend.db.Where("id = ?", eventId).First(&event)
+        if res.Error != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Event not found.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        var parts []table.EventParticipant
+        res = backend.db.Preload("User").
+            Where("event_id = ?", eventId).
+            Order("id").
+            Find(&parts)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the participant, %v", res.Error),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        rows := [][]string{append([]string{"name", "email", "role", "status", "position", "came", "registered_at"}, formAnswerHeader(event.EventForm)...)}
+        for _, part := range parts {
+            position := ""
+            if part.EventPWaitPos != nil {
+                position = strconv.Itoa(*part.EventPWaitPos)
+            }
+            row := []string{
+                part.User.UserFullName,
+                part.User.UserEmail,
+                string(part.EventPRole),
+                string(part.EventPStatus),
+                position,
+                strconv.FormatBool(part.EventPCome),
+                part.CreatedAt.Format("2006-01-02 15:04:05"),
+            }
+            row = append(row, formAnswerRow(event.EventForm, part.EventPAnswers)...)
+            for i := range row {
+                row[i] = csvSafe(row[i])
+            }
+            rows = append(rows, row)
+        }
+
+        var buf bytes.Buffer
+        if err := csv.NewWriter(&buf).WriteAll(rows); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to write the csv, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+        c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"participant-event-%d.csv\"", eventId))
+        return c.Status(fiber.StatusOK).Send(buf.Bytes())
+    })
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+// NOTE: Same check as event-participate-edit, false mean the error response
+//       is already written and err is what the handler should return. What
+//       is only for the message.
+func eventCommitteeCheck(backend *Backend, c *fiber.Ctx, eventId int, what string) (bool, error) {
+    _, err := requireEventRole(backend, c, eventId, table.CommitteeU)
+    if err == nil {
+        return true, nil
+    }
+    if authzStatus(err) != fiber.StatusUnauthorized {
+        return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": fmt.Sprintf("Failed to fetch current user participation, %v", err),
+            "error_code": 2,
+            "data": nil,
+        })
+    }
+    return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+        "success": false,
+        "message": fmt.Sprintf("Only committee members and admins can %s.", what),
+        "error_code": 3,
+        "data": nil,
+    })
+}
+
+// NOTE: In the order they will be promoted.
+// GET : api/protected/event-participate-waitlist
+func appHandleEventParticipateWaitlist(backend *Backend, route fiber.Router) {
+    route.Get("event-participate-waitlist", func (c *fiber.Ctx) error {
+        eventId, err := strconv.Atoi(c.Query("event_id"))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid event_id on query.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if ok, err := eventCommitteeCheck(backend, c, eventId, "manage the waitlist"); !ok {
+            return err
+        }
+
+        var waiting []table.EventParticipant
+        res := backend.db.Preload("User").
+            Where("event_id = ? AND eventp_status = ?", eventId, table.WaitlistedP).
+            Order("eventp_wait_pos ASC, id ASC").
+            Find(&waiting)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the waitlist from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": waiting,
+        })
+    })
+}
+
+// NOTE: `emails` is the new front of the waitlist, whoever is not on it keep
+//       their order behind them.
+// POST : api/protected/event-participate-waitlist-reorder
+func appHandleEventParticipateWaitlistReorder(backend *Backend, route fiber.Router) {
+    route.Post("event-participate-waitlist-reorder", func (c *fiber.Ctx) error {
+        var body struct {
+            EventID int      `json:"event_id"`
+            Emails  []string `json:"emails"`
+        }
+
+        err := c.BodyParser(&body)
+        if err != nil || len(body.Emails) == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, event_id and emails are required.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if ok, err := eventCommitteeCheck(backend, c, body.EventID, "manage the waitlist"); !ok {
+            return err
+        }
+
+        var waiting []table.EventParticipant
+        res := backend.db.Preload("User").
+            Where("event_id = ? AND eventp_status = ?", body.EventID, table.WaitlistedP).
+            Order("eventp_wait_pos ASC, id ASC").
+            Find(&waiting)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the waitlist from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        byEmail := map[string]int{}
+        for i, part := range waiting {
+            byEmail[part.User.UserEmail] = i
+        }
+        order := make([]int, 0, len(waiting))
+        moved := map[int]bool{}
+        for _, email := range body.Emails {
+            i, ok := byEmail[email]
+            if !ok {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("%s is not on the waitlist of this event.", email),
+                    "error_code": 4,
+                    "data": nil,
+                })
+            }
+            if !moved[i] {
+                moved[i] = true
+                order = append(order, i)
+            }
+        }
+        for i := range waiting {
+            if !moved[i] {
+                order = append(order, i)
+            }
+        }
+
+        err = backend.db.Transaction(func(tx *gorm.DB) error {
+            for pos, i := range order {
+                err := tx.Model(&table.EventParticipant{}).
+                    Where("id = ? AND eventp_status = ?", waiting[i].ID, table.WaitlistedP).
+                    Update("eventp_wait_pos", pos + 1).
+                    Error
+                if err != nil {
+                    return err
+                }
+            }
+            return nil
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to reorder the waitlist, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Waitlist reordered.",
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
+
+// NOTE: The committee can promote anyone on the waitlist even when the event
+//       is full, it is their call to go over EventMax.
+// POST : api/protected/event-participate-waitlist-promote
+func appHandleEventParticipateWaitlistPromote(backend *Backend, route fiber.Router) {
+    route.Post("event-participate-waitlist-promote", func (c *fiber.Ctx) error {
+        var body struct {
+            EventID   int    `json:"event_id"`
+            UserEmail string `json:"email"`
+        }
+
+        err := c.BodyParser(&body)
+        if err != nil || body.UserEmail == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid body request, event_id and email are required.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if ok, err := eventCommitteeCheck(backend, c, body.EventID, "manage the waitlist"); !ok {
+            return err
+        }
+
+        var part table.EventParticipant
+        res := backend.db.Preload("User").Preload("Event").
+            Joins("JOIN users ON users.id = event_participants.user_id").
+            Where("event_participants.event_id = ? AND event_participants.eventp_status = ? AND users.user_email = ?", body.EventID, table.WaitlistedP, body.UserEmail).
+            First(&part)
+        if res.Error != nil {
+            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "This user is not on the waitlist of this event.",
+                    "error_code": 4,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the waitlist from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        ok := false
+        err = backend.db.Transaction(func(tx *gorm.DB) error {
+            if _, err := lockEvent(tx, body.EventID); err != nil {
+                return err
+            }
+            ok, err = promoteParticipant(tx, &part)
+            if err != nil || !ok {
+                return err
+            }
+            return compactWaitlist(tx, body.EventID)
+        })
+        if err == nil && !ok {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "This user is not on the waitlist of this event.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to promote the participant, %v", err),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        notifyPromoted(backend, &part, &part.Event)
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Participant promoted.",
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// GET : api/protected/job-list
+func appHandleJobList(backend *Backend, route fiber.Router) {
+    route.Get("job-list", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var runs []table.JobRun
+        res := backend.db.Find(&runs)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the job run from db, %v", res.Error),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        lastRun := map[string]*table.JobRun{}
+        for i := range runs {
+            lastRun[runs[i].JobName] = &runs[i]
+        }
+
+        type jobInfo struct {
+            Name    string
+            Spec    string
+            Running bool
+            NextRun *time.Time
+            LastRun *table.JobRun
+        }
+        result := make([]jobInfo, 0, len(backend.scheduler.jobs))
+        for _, job := range backend.scheduler.jobs {
+            result = append(result, jobInfo{
+                Name:    job.Name,
+                Spec:    job.Spec,
+                Running: job.running.Load(),
+                NextRun: backend.scheduler.Next(job),
+                LastRun: lastRun[job.Name],
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": result,
+        })
+    })
+}
+
+// NOTE: Run the job right away and wait for it, the response have the outcome.
+// POST : api/protected/job-run
+func appHandleJobRun(backend *Backend, route fiber.Router) {
+    route.Post("job-run", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            Name string `json:"name"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        job := backend.scheduler.Job(body.Name)
+        if job == nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("No job named %q.", body.Name),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        run, err := runJob(backend, job, true)
+        if err != nil {
+            if errors.Is(err, errJobRunning) {
+                return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The job is already running.",
+                    "error_code": 5,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("The job failed, %v", err),
+                "error_code": 6,
+                "data": run,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Job done.",
+            "error_code": 0,
+            "data": run,
+        })
+    })
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+// NOTE: kind can be `login` or `otp`, email filter to one account,
+//       both are optional.
+// GET : api/protected/lockout-list
+func appHandleLockoutList(backend *Backend, route fiber.Router) {
+    route.Get("lockout-list", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        offset, err := strconv.Atoi(c.Query("offset", "0"))
+        if err != nil {
+            offset = 0
+        }
+        limit, err := strconv.Atoi(c.Query("limit", "100"))
+        if err != nil {
+            limit = 100
+        }
+
+        query := backend.db.Model(&table.Lockout{})
+        if kind := c.Query("kind"); kind != "" {
+            query = query.Where("lock_kind = ?", kind)
+        }
+        if email := c.Query("email"); email != "" {
+            query = query.Where("lock_email = ?", email)
+        }
+
+        var lockouts []table.Lockout
+        res := query.Offset(offset).Limit(limit).
+            Order("created_at DESC").
+            Find(&lockouts)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the lockout from db, %v", res.Error),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": lockouts,
+        })
+    })
+}
+
+// NOTE: Only clear the account lock, a locked otp is cleared by requesting a new one.
+// POST : api/protected/user-unlock
+func appHandleUserUnlock(backend *Backend, route fiber.Router) {
+    route.Post("user-unlock", func (c *fiber.Ctx) error {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT Token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials to acces this api.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            Email string `json:"email"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid body request, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        var target table.User
+        res := backend.db.Where("user_email = ?", body.Email).First(&target)
+        if res.Error != nil {
+            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The email specified is not registered.",
+                    "error_code": 4,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("There is an error with the db, %v", res.Error),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        // NOTE: Always write, the struct may be stale if the lock just happen.
+        res = backend.db.Model(&table.User{}).
+            Where("id = ?", target.ID).
+            Updates(map[string]any{
+                "user_failed_login": 0,
+                "user_locked_until": nil,
+            })
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to unlock the user, %v", res.Error),
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "User unlocked.",
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
@@ -1,17 +1,19 @@
 package main
 
 import (
+    "errors"
     "fmt"
     "strconv"
     "webrpl/table"
 
     "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
 )
 
 // POST : api/protected/material-register
 func appHandleMaterialNew(backend *Backend, route fiber.Router) {
     route.Post("material-register", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -20,17 +22,6 @@ func appHandleMaterialNew(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-
-        isAdmin := claims["admin"].(float64)
-
-        if isAdmin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid credentials for this function",
-                "error_code": 2,
-                "data": nil,
-            })
-        }
         var body struct {
             EventId      int    `json:"id"`
             EventAttach  string `json:"event_attach"`
@@ -46,6 +37,16 @@ func appHandleMaterialNew(backend *Backend, route fiber.Router) {
             })
         }
 
+        _, err = requireEventRole(backend, c, body.EventId, table.CommitteeU)
+        if err != nil {
+            return c.Status(authzStatus(err)).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials for this function",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
         var event table.Event
         res := backend.db.Where("id = ?", body.EventId).First(&event)
         if res.Error != nil {
@@ -84,7 +85,7 @@ func appHandleMaterialNew(backend *Backend, route fiber.Router) {
 // GET : api/protected/material-info-of
 func appHandleMaterialInfoOf(backend *Backend, route fiber.Router) {
     route.Get("material-info-of", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -94,7 +95,7 @@ func appHandleMaterialInfoOf(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         if email == "" {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -150,7 +151,7 @@ func appHandleMaterialInfoOf(backend *Backend, route fiber.Router) {
 // POST : api/protected/material-del
 func appHandleMaterialDel(backend *Backend, route fiber.Router) {
     route.Post("material-del", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -160,16 +161,6 @@ func appHandleMaterialDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-        if isAdmin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid credentials for this function",
-                "error_code": 2,
-                "data": nil,
-            })
-        }
-
         var body struct {
             EventMatId int `json:"id"`
         }
@@ -184,7 +175,32 @@ func appHandleMaterialDel(backend *Backend, route fiber.Router) {
             })
         }
 
-        res := backend.db.Delete(&table.EventMaterial{}, body.EventMatId)
+        // NOTE: Deleting a missing material is still ok like before, there is
+        //       just no event to check the committee against.
+        var eventMaterial table.EventMaterial
+        res := backend.db.First(&eventMaterial, body.EventMatId)
+        if res.Error != nil && !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Failed to delete event material from the DB.",
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        if res.Error == nil {
+            _, err = requireEventRole(backend, c, eventMaterial.EventId, table.CommitteeU)
+            if err != nil {
+                return c.Status(authzStatus(err)).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Invalid credentials for this function",
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
+        }
+
+        res = backend.db.Delete(&table.EventMaterial{}, body.EventMatId)
         if res.Error != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -206,7 +222,7 @@ func appHandleMaterialDel(backend *Backend, route fiber.Router) {
 // POST : api/protected/material-edit
 func appHandleMaterialEdit(backend *Backend, route fiber.Router) {
     route.Post("material-edit", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        _, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -216,16 +232,6 @@ func appHandleMaterialEdit(backend *Backend, route fiber.Router) {
             })
         }
 
-        isAdmin := claims["admin"].(float64)
-        if isAdmin != 1 {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "success": false,
-                "message": "Invalid credentials for this function",
-                "error_code": 2,
-                "data": nil,
-            })
-        }
-
         var body struct {
             Id           int     `json:"id"`
             EventId      *int    `json:"event_id"`
@@ -252,7 +258,28 @@ func appHandleMaterialEdit(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        if body.EventId != nil {
+
+        _, err = requireEventRole(backend, c, eventMaterial.EventId, table.CommitteeU)
+        if err != nil {
+            return c.Status(authzStatus(err)).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid credentials for this function",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        // NOTE: Moving it to other event need committee right over there too.
+        if body.EventId != nil && *body.EventId != eventMaterial.EventId {
+            _, err = requireEventRole(backend, c, *body.EventId, table.CommitteeU)
+            if err != nil {
+                return c.Status(authzStatus(err)).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Invalid credentials for this function",
+                    "error_code": 2,
+                    "data": nil,
+                })
+            }
             eventMaterial.EventId = *body.EventId
         }
 
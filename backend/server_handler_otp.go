@@ -10,7 +10,8 @@ import (
 	"gorm.io/gorm"
 )
 
-// NOTE: Gen otp for the inserted email
+// NOTE: Gen otp for the inserted email, `purpose` is `register` (default) or
+//       `reset-password`. The other purpose are made by the flow that need them.
 // GET : api/gen-otp-for-register
 func appHandleGenOTP(backend *Backend, route fiber.Router) {
     route.Get("gen-otp-for-register", func (c *fiber.Ctx) error {
@@ -27,6 +28,25 @@ func appHandleGenOTP(backend *Backend, route fiber.Router) {
             })
         }
 
+        purpose := table.OTPPurposeEnum(c.Query("purpose", string(table.OTPRegister)))
+        if purpose != table.OTPRegister && purpose != table.OTPResetPassword {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid OTP purpose.",
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        if !backend.limiters.otp.Allow(c, email) {
+            return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+                "success": false,
+                "message": "Too many OTP requested, please try again later.",
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
         // Check if the user with that email exist
         sqlError := backend.db.Where("user_email = ?", email).First(&table.User{}).Error
         if sqlError != nil {
@@ -38,9 +58,20 @@ func appHandleGenOTP(backend *Backend, route fiber.Router) {
                     "data": nil,
                 })
             }
+
+            // NOTE: Same answer as the normal one, so this cant be used to
+            //       find out which email is registered.
+            if purpose == table.OTPResetPassword {
+                return c.Status(fiber.StatusOK).JSON(fiber.Map{
+                    "success": true,
+                    "message": "Generated the OTP please check console or email.",
+                    "error_code": 0,
+                    "data": nil,
+                })
+            }
         }
 
-        newOTP, err := createOTPCode(backend, 4, email)
+        code, newOTP, err := createOTPCode(backend, purpose, email)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -50,11 +81,19 @@ func appHandleGenOTP(backend *Backend, route fiber.Router) {
             })
         }
 
-        fmt.Printf(" -###- The Generated OTP code are : %s -###-\n", newOTP.OtpCode)
-        if !sendEmailTo(backend, newOTP.UserEmail, "OTP code for webrpl", fmt.Sprintf("Your OTP code are : %s\n(Working for 5 mins)", newOTP.OtpCode)) {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        if backend.config.IsDev() {
+            fmt.Printf(" -###- The Generated %s OTP code are : %s -###-\n", purpose, code)
+        }
+        _, err = queueEmail(backend, &Mail{
+            To:        []string{newOTP.UserEmail},
+            Subject:   "OTP code for webrpl",
+            Text:      fmt.Sprintf("Your %s OTP code are : %s\n(Working for %s)", purpose, code, backend.config.OTP.TTL),
+            Sensitive: true,
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": "Failed to send email.",
+                "message": fmt.Sprintf("Failed to queue the email, %v", err),
                 "error_code": 4,
                 "data": nil,
             })
@@ -72,8 +111,8 @@ func appHandleGenOTP(backend *Backend, route fiber.Router) {
 // POST : api/protected/cleanup-otp-code
 func appHandleCleanupOTP(backend *Backend, route fiber.Router) {
     route.Post("cleanup-otp-code", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT Token.",
@@ -82,8 +121,7 @@ func appHandleCleanupOTP(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid Credentials.",
@@ -92,12 +130,22 @@ func appHandleCleanupOTP(backend *Backend, route fiber.Router) {
             })
         }
 
-        CleanupOTPTable(backend)
+        // NOTE: Go through the job runner so it show up on job-list.
+        run, err := runJob(backend, backend.scheduler.Job("otp_cleanup"), true)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to cleanup the OTP code, %v", err),
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "Unused OTP code cleaned up.",
             "error_code": 0,
-            "data": nil,
+            "data": run,
         })
     })
 }
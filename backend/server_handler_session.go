@@ -0,0 +1,270 @@
+package main
+
+import (
+    "fmt"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// NOTE: The refresh token can come from the body (bearer client) or from the
+//       `refresh` cookie (browser), it is rotated on every call so the old one
+//       is useless after this.
+// POST : api/token/refresh
+func appHandleTokenRefresh(backend *Backend, route fiber.Router) {
+    route.Post("token/refresh", func (c *fiber.Ctx) error {
+        var body struct {
+            RefreshToken string `json:"refresh_token"`
+        }
+
+        if len(c.Body()) > 0 {
+            err := c.BodyParser(&body)
+            if err != nil {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Invalid request body, %v", err),
+                    "error_code": 1,
+                    "data": nil,
+                })
+            }
+        }
+
+        refresh := body.RefreshToken
+        if refresh == "" {
+            refresh = c.Cookies("refresh")
+        }
+        if refresh == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "No refresh token supplied.",
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        var session table.Session
+        res := backend.db.Preload("User").
+            Where("session_refresh = ? AND session_revoked_at IS NULL AND session_expire > ?", hashToken(refresh), time.Now()).
+            First(&session)
+        if res.Error != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Refresh token is invalid, expired or revoked.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        newRefresh, err := randomToken(32)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to generate refresh token, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        // NOTE: Conditional on the old hash, so two refresh with the same token
+        //       at the same time only one of them win.
+        now := time.Now()
+        refreshExpire := now.Add(backend.config.Auth.RefreshTTL)
+        res = backend.db.Model(&table.Session{}).
+            Where("id = ? AND session_refresh = ?", session.ID, session.SessionRefresh).
+            Updates(map[string]any{
+                "session_refresh":   hashToken(newRefresh),
+                "session_expire":    refreshExpire,
+                "session_last_used": now,
+                "session_ip":        c.IP(),
+                "session_agent":     c.Get(fiber.HeaderUserAgent),
+            })
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to update the session, %v", res.Error),
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+        if res.RowsAffected == 0 {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Refresh token is invalid, expired or revoked.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        t, accessExpire, err := signAccessToken(backend, &session.User, &session)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to generate JWT, %v", err),
+                "error_code": 6,
+                "data": nil,
+            })
+        }
+
+        setAuthCookies(backend, c, t, accessExpire, newRefresh, refreshExpire)
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Token refreshed.",
+            "error_code": 0,
+            "data": nil,
+            "token": t,
+            "refresh_token": newRefresh,
+        })
+    })
+}
+
+// GET : api/protected/session-list
+func appHandleSessionList(backend *Backend, route fiber.Router) {
+    route.Get("session-list", func (c *fiber.Ctx) error {
+        current, err := currentSession(c)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        var sessions []table.Session
+        res := backend.db.
+            Where("user_id = ? AND session_revoked_at IS NULL AND session_expire > ?", current.UserId, time.Now()).
+            Order("session_last_used DESC").
+            Find(&sessions)
+        if res.Error != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to fetch the session from db, %v", res.Error),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        type sessionInfo struct {
+            table.Session
+            Current bool
+        }
+        result := make([]sessionInfo, 0, len(sessions))
+        for _, session := range sessions {
+            result = append(result, sessionInfo{
+                Session: session,
+                Current: session.ID == current.ID,
+            })
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Check data.",
+            "error_code": 0,
+            "data": result,
+        })
+    })
+}
+
+// POST : api/protected/session-revoke
+func appHandleSessionRevoke(backend *Backend, route fiber.Router) {
+    route.Post("session-revoke", func (c *fiber.Ctx) error {
+        current, err := currentSession(c)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        var body struct {
+            ID int `json:"id"`
+        }
+
+        err = c.BodyParser(&body)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Invalid request body, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        if body.ID <= 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Session id is required.",
+                "error_code": 3,
+                "data": nil,
+            })
+        }
+
+        count, err := revokeSession(backend, current.UserId, body.ID)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to revoke the session, %v", err),
+                "error_code": 4,
+                "data": nil,
+            })
+        }
+
+        if count == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Session not found or already revoked.",
+                "error_code": 5,
+                "data": nil,
+            })
+        }
+
+        if body.ID == current.ID {
+            clearAuthCookies(backend, c)
+        }
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": "Session revoked.",
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
+
+// POST : api/protected/logout-all
+func appHandleLogOutAll(backend *Backend, route fiber.Router) {
+    route.Post("logout-all", func (c *fiber.Ctx) error {
+        current, err := currentSession(c)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT token.",
+                "error_code": 1,
+                "data": nil,
+            })
+        }
+
+        count, err := revokeUserSessions(backend, current.UserId, 0)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to revoke the session, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        clearAuthCookies(backend, c)
+
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "success": true,
+            "message": fmt.Sprintf("Logged out from %d session.", count),
+            "error_code": 0,
+            "data": nil,
+        })
+    })
+}
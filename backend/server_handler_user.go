@@ -4,14 +4,15 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 	"webrpl/table"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
@@ -43,13 +44,19 @@ func appHandleUserResetPass(backend *Backend, route fiber.Router) {
             })
         }
 
-        var selUser table.User
-        var selOTP table.OTP
-        res := backend.db.Where("user_email = ? AND otp_code = ?", body.Email, body.OtpCode).First(&selOTP)
-        res2 := backend.db.Where("user_email = ?", body.Email).First(&selUser)
+        if !backend.limiters.verify.Allow(c, body.Email) {
+            return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+                "success": false,
+                "message": "Too many attempt, please try again later.",
+                "error_code": 8,
+                "data": nil,
+            })
+        }
 
-        if (res.Error != nil || res2.Error != nil) {
-            if !errors.Is(res.Error, gorm.ErrRecordNotFound) && !errors.Is(res2.Error, gorm.ErrRecordNotFound) {
+        var selUser table.User
+        res := backend.db.Where("user_email = ?", body.Email).First(&selUser)
+        if res.Error != nil {
+            if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
                 return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                     "success": false,
                     "message": "Failed to fetch user or otp from the db.",
@@ -65,11 +72,35 @@ func appHandleUserResetPass(backend *Backend, route fiber.Router) {
             })
         }
 
-        if IsOTPExpired(&selOTP) || selOTP.Used {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        selOTP, err := verifyOTP(backend, c, table.OTPResetPassword, body.Email, body.OtpCode)
+        if err != nil {
+            switch {
+            case errors.Is(err, errOTPInvalid):
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "There is no otp or user with that email registered.",
+                    "error_code": 4,
+                    "data": nil,
+                })
+            case errors.Is(err, errOTPExpired):
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The specified OTP is expired. Please request new code.",
+                    "error_code": 5,
+                    "data": nil,
+                })
+            case errors.Is(err, errOTPLocked):
+                return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Too many wrong OTP code. Please request new code.",
+                    "error_code": 9,
+                    "data": nil,
+                })
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": "The specified OTP is expired. Please request new code.",
-                "error_code": 5,
+                "message": "Failed to fetch user or otp from the db.",
+                "error_code": 3,
                 "data": nil,
             })
         }
@@ -84,17 +115,36 @@ func appHandleUserResetPass(backend *Backend, route fiber.Router) {
             })
         }
 
+        // NOTE: The otp is consumed on the same transaction, so the same code
+        //       cant reset the password twice.
         selUser.UserPassword = hashedPassword
-        res = backend.db.Save(&selUser)
-        if res.Error != nil {
+        err = backend.db.Transaction(func(tx *gorm.DB) error {
+            if err := consumeOTP(tx, selOTP); err != nil {
+                return err
+            }
+            return tx.Save(&selUser).Error
+        })
+        if err != nil {
+            if errors.Is(err, errOTPExpired) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The specified OTP is expired. Please request new code.",
+                    "error_code": 5,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to update user password, %v", res.Error),
+                "message": fmt.Sprintf("Failed to update user password, %v", err),
                 "error_code": 7,
                 "data": nil,
             })
         }
-        selOTP.Used = true
+
+        // NOTE: Whoever had the old password should not stay logged in.
+        if _, err := revokeUserSessions(backend, selUser.ID, 0); err != nil {
+            log.Printf("WARN: Failed to revoke session of %s, %v", selUser.UserEmail, err)
+        }
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
@@ -141,6 +191,19 @@ func appHandleLogin(backend *Backend, route fiber.Router) {
             })
         }
 
+        // NOTE: Only the failed login count, so a shared ip that login fine
+        //       all day dont get blocked. The attempt is reserved before the
+        //       password check and given back on success, counting only
+        //       after a failure let a burst of parallel guess all pass.
+        if !backend.limiters.login.Allow(c, body.UserEmail) {
+            return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+                "success": false,
+                "message": "Too many failed login, please try again later.",
+                "error_code": 8,
+                "data": nil,
+            })
+        }
+
         var user table.User;
         res := backend.db.Where("user_email = ?", body.UserEmail).First(&user)
 
@@ -153,8 +216,32 @@ func appHandleLogin(backend *Backend, route fiber.Router) {
             })
         }
 
+        // NOTE: Checked before the password so a locked account dont tell
+        //       if the guess was right.
+        if wait := userLockedFor(&user); wait > 0 {
+            c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(wait.Seconds())+1))
+            return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Account is locked after too many wrong password, try again in %s.", wait.Round(time.Second)),
+                "error_code": 9,
+                "data": nil,
+            })
+        }
+
         validPass := CheckPassword(user.UserPassword, body.UserPassword)
         if !validPass {
+            locked, err := recordLoginFailure(backend, c, &user)
+            if err != nil {
+                log.Printf("WARN: Failed to count the failed login of %s, %v", user.UserEmail, err)
+            }
+            if locked {
+                return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Too many wrong password, the account is locked for a while.",
+                    "error_code": 9,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Wrong Password",
@@ -163,15 +250,22 @@ func appHandleLogin(backend *Backend, route fiber.Router) {
             })
         }
 
-        claims := jwt.MapClaims{
-            "email":  user.UserEmail,
-            "admin": user.UserRole,
-            "exp":   time.Now().Add(time.Hour * 72).Unix(),
+        backend.limiters.login.Refund(c, body.UserEmail)
+        if err := clearLoginFailure(backend, &user); err != nil {
+            log.Printf("WARN: Failed to reset the failed login of %s, %v", user.UserEmail, err)
         }
 
-        token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+        session, refresh, err := createSession(backend, c, &user)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to create session, %v", err),
+                "error_code": 7,
+                "data": nil,
+            })
+        }
 
-        t, err := token.SignedString([]byte(backend.pass))
+        t, accessExpire, err := signAccessToken(backend, &user, session)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -181,14 +275,7 @@ func appHandleLogin(backend *Backend, route fiber.Router) {
             })
         }
 
-        c.Cookie(&fiber.Cookie{
-            Name:     "jwt",
-            Value:    t,
-            HTTPOnly: true,
-            Secure:   false,
-            SameSite: "Lax",
-            Expires:  time.Now().Add(72 * time.Hour),
-        })
+        setAuthCookies(backend, c, t, accessExpire, refresh, session.SessionExpire)
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
@@ -196,6 +283,7 @@ func appHandleLogin(backend *Backend, route fiber.Router) {
             "data": user,
             "error_code": 0,
             "token": t,
+            "refresh_token": refresh,
         })
     })
 }
@@ -203,8 +291,8 @@ func appHandleLogin(backend *Backend, route fiber.Router) {
 // GET : api/protected/user-info-of
 func appHandleUserInfoOf(backend *Backend, route fiber.Router) {
     route.Get("user-info-of", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT token.",
@@ -213,8 +301,7 @@ func appHandleUserInfoOf(backend *Backend, route fiber.Router) {
             })
         }
 
-        admin := claims["admin"].(float64)
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to access this api.",
@@ -265,8 +352,8 @@ func appHandleUserInfoOf(backend *Backend, route fiber.Router) {
 func appHandleUserEditAdmin(backend *Backend, route fiber.Router){
     route.Post("/user-edit-admin", func (c *fiber.Ctx) error {
 
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT Token.",
@@ -274,8 +361,7 @@ func appHandleUserEditAdmin(backend *Backend, route fiber.Router){
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to acces this api.",
@@ -290,6 +376,7 @@ func appHandleUserEditAdmin(backend *Backend, route fiber.Router){
             Instance string `json:"instance"`
             Picture  string `json:"picture"`
             Password *string `json:"password"`
+            UserRole *int    `json:"user_role"`
         }
 
         err = c.BodyParser(&body)
@@ -311,7 +398,39 @@ func appHandleUserEditAdmin(backend *Backend, route fiber.Router){
             })
         }
 
+        var target table.User
+        res := backend.db.Where("user_email = ?", body.Email).First(&target)
+        if res.Error != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User not found or no changes made.",
+                "error_code": 7,
+                "data": nil,
+            })
+        }
+
+        if !canManageUser(user.UserRole, target.UserRole) {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Only super-admin can edit another admin.",
+                "error_code": 8,
+                "data": nil,
+            })
+        }
+
         updates := make(map[string]any)
+        if body.UserRole != nil {
+            role := table.UserRoleEnum(*body.UserRole)
+            if !canGrantRole(user.UserRole, role) {
+                return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Invalid role or not allowed to give that role.",
+                    "error_code": 9,
+                    "data": nil,
+                })
+            }
+            updates["user_role"] = role
+        }
         if body.FullName != "" {
             updates["user_full_name"] = body.FullName
         }
@@ -356,6 +475,12 @@ func appHandleUserEditAdmin(backend *Backend, route fiber.Router){
             })
         }
 
+        if _, ok := updates["user_password"]; ok {
+            if _, err := revokeUserSessions(backend, target.ID, 0); err != nil {
+                log.Printf("WARN: Failed to revoke session of %s, %v", target.UserEmail, err)
+            }
+        }
+
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "Data modified.",
@@ -372,8 +497,8 @@ func appHandleUserDelAdmin(backend *Backend, route fiber.Router){
             UserID int `json:"id"`
         }
 
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Failed to claim JWT Token.",
@@ -381,6 +506,7 @@ func appHandleUserDelAdmin(backend *Backend, route fiber.Router){
                 "data": nil,
             })
         }
+        isAdmin := err == nil
 
         err = c.BodyParser(&body)
         if err != nil {
@@ -392,8 +518,13 @@ func appHandleUserDelAdmin(backend *Backend, route fiber.Router){
             })
         }
 
-        admin := claims["admin"].(float64)
-        if admin != 1 {
+        var target table.User
+        if isAdmin {
+            if err := backend.db.First(&target, body.UserID).Error; err != nil {
+                isAdmin = false
+            }
+        }
+        if !isAdmin || !canManageUser(user.UserRole, target.UserRole) {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to acces this api.",
@@ -412,6 +543,10 @@ func appHandleUserDelAdmin(backend *Backend, route fiber.Router){
             })
         }
 
+        if _, err := revokeUserSessions(backend, body.UserID, 0); err != nil {
+            log.Printf("WARN: Failed to revoke session of user %d, %v", body.UserID, err)
+        }
+
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "User deleted.",
@@ -424,7 +559,7 @@ func appHandleUserDelAdmin(backend *Backend, route fiber.Router){
 // POST: api/protected/user-edit
 func appHandleUserEdit(backend *Backend, route fiber.Router) {
     route.Post("/user-edit", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -434,7 +569,7 @@ func appHandleUserEdit(backend *Backend, route fiber.Router) {
             })
         }
 
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         var body struct {
             FullName    *string  `json:"name"`
@@ -485,6 +620,7 @@ func appHandleUserEdit(backend *Backend, route fiber.Router) {
             currentUser.UserPicture = *body.Picture
         }
 
+        passwordChanged := false
         if (body.Password != nil && *body.Password != "") && (body.OldPassword != nil && *body.OldPassword != "") {
 
             if !CheckPassword(currentUser.UserPassword, *body.OldPassword) {
@@ -506,6 +642,7 @@ func appHandleUserEdit(backend *Backend, route fiber.Router) {
                 })
             }
             currentUser.UserPassword = hashedPassword
+            passwordChanged = true
         }
 
         result := backend.db.Save(&currentUser)
@@ -526,6 +663,18 @@ func appHandleUserEdit(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
+
+        // NOTE: Keep the device that change the password logged in.
+        if passwordChanged {
+            exceptId := 0
+            if session, err := currentSession(c); err == nil {
+                exceptId = session.ID
+            }
+            if _, err := revokeUserSessions(backend, currentUser.ID, exceptId); err != nil {
+                log.Printf("WARN: Failed to revoke session of %s, %v", currentUser.UserEmail, err)
+            }
+        }
+
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "Data modified.",
@@ -557,8 +706,8 @@ func appHandleUserInfoAll(backend *Backend, route fiber.Router) {
             limit = 10000
         }
 
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid JWT Token.",
@@ -566,9 +715,8 @@ func appHandleUserInfoAll(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
 
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to acces this api.",
@@ -602,7 +750,7 @@ func appHandleUserInfoAll(backend *Backend, route fiber.Router) {
 func appHandleUserInfo(backend *Backend, route fiber.Router) {
     route.Get("user-info", func (c *fiber.Ctx) error {
 
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
@@ -611,7 +759,7 @@ func appHandleUserInfo(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         var userData table.User
         res := backend.db.Where("user_email = ?", email).First(&userData)
@@ -640,7 +788,7 @@ func appHandleUserUploadImage(backend *Backend, route fiber.Router) {
             Data string `json:"data"`
         }
 
-        claims, err := GetJWT(c)
+        user, err := currentUser(c)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -649,7 +797,7 @@ func appHandleUserUploadImage(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        email := claims["email"].(string)
+        email := user.UserEmail
 
         err = c.BodyParser(&body)
         if err != nil {
@@ -670,7 +818,7 @@ func appHandleUserUploadImage(backend *Backend, route fiber.Router) {
             })
         }
 
-        imgDir := "static"
+        imgDir := backend.config.Upload.StaticDir
         if err := os.MkdirAll(imgDir, 0755); err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -717,7 +865,8 @@ func appHandleUserUploadImage(backend *Backend, route fiber.Router) {
             fileExt = ".webp"
         }
 
-        filename := fmt.Sprintf("%s/%s%s", imgDir, username, fileExt)
+        name := fmt.Sprintf("%s%s", username, fileExt)
+        filename := filepath.Join(imgDir, name)
 
         err = os.WriteFile(filename, imageData, 0644)
         if err != nil {
@@ -734,7 +883,7 @@ func appHandleUserUploadImage(backend *Backend, route fiber.Router) {
             "message": "Image uploaded successfully",
             "error_code": 0,
             "data": fiber.Map{
-                "filename": fmt.Sprintf("%s://%s/%s", backend.mode, backend.address, filename),
+                "filename": publicURL(backend, "static/"+name),
             },
         })
     })
@@ -780,6 +929,15 @@ func appHandleRegister(backend *Backend, route fiber.Router) {
             })
         }
 
+        if !backend.limiters.verify.Allow(c, body.Email) {
+            return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+                "success": false,
+                "message": "Too many attempt, please try again later.",
+                "error_code": 13,
+                "data": nil,
+            })
+        }
+
         var userData table.User
         res := backend.db.Where("user_email = ?", body.Email).First(&userData)
         if res.Error != nil && !errors.Is(res.Error, gorm.ErrRecordNotFound) {
@@ -801,34 +959,39 @@ func appHandleRegister(backend *Backend, route fiber.Router) {
         }
 
         // Do the OTP check.
-        var selOTP table.OTP
-        res = backend.db.Where("otp_code = ? AND user_email = ?", body.OTPCode, body.Email).First(&selOTP)
-        if res.Error != nil {
-            if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+        selOTP, err := verifyOTP(backend, c, table.OTPRegister, body.Email, body.OTPCode)
+        if err != nil {
+            switch {
+            case errors.Is(err, errOTPInvalid):
                 return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                     "success": false,
                     "message": "The specified OTP doesnt exist.",
                     "error_code": 10,
                     "data": nil,
                 })
+            case errors.Is(err, errOTPExpired):
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The specified OTP is expired. Please request new code.",
+                    "error_code": 11,
+                    "data": nil,
+                })
+            case errors.Is(err, errOTPLocked):
+                return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Too many wrong OTP code. Please request new code.",
+                    "error_code": 12,
+                    "data": nil,
+                })
             }
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to get the otp table, %v", res.Error),
+                "message": fmt.Sprintf("Failed to get the otp table, %v", err),
                 "error_code": 9,
                 "data": nil,
             })
         }
 
-        if IsOTPExpired(&selOTP) || selOTP.Used {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-                "success": false,
-                "message": "The specified OTP is expired. Please request new code.",
-                "error_code": 11,
-                "data": nil,
-            })
-        }
-
         hashedPassword, err := HashPassword(body.Password)
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -849,18 +1012,29 @@ func appHandleRegister(backend *Backend, route fiber.Router) {
             UserCreatedAt: time.Now(),
         }
 
-        result := backend.db.Create(&newUser)
-        if result.Error != nil {
+        err = backend.db.Transaction(func(tx *gorm.DB) error {
+            if err := consumeOTP(tx, selOTP); err != nil {
+                return err
+            }
+            return tx.Create(&newUser).Error
+        })
+        if err != nil {
+            if errors.Is(err, errOTPExpired) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "The specified OTP is expired. Please request new code.",
+                    "error_code": 11,
+                    "data": nil,
+                })
+            }
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": fmt.Sprintf("Failed to write to db, %v", result.Error),
+                "message": fmt.Sprintf("Failed to write to db, %v", err),
                 "error_code": 8,
                 "data": nil,
             })
         }
 
-        selOTP.Used = true
-
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
             "message": "successfully created new user",
@@ -874,8 +1048,8 @@ func appHandleRegister(backend *Backend, route fiber.Router) {
 func appHandleUserCount(backend *Backend, route fiber.Router) {
     route.Get("/user-count", func (c *fiber.Ctx) error {
 
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Failed to claim JWT Token.",
@@ -883,9 +1057,8 @@ func appHandleUserCount(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
 
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to acces this api.",
@@ -915,8 +1088,8 @@ func appHandleUserCount(backend *Backend, route fiber.Router) {
 // POST : api/protected/register-admin
 func appHandleRegisterAdmin(backend *Backend, route fiber.Router) {
     route.Post("register-admin", func (c *fiber.Ctx) error {
-        claims, err := GetJWT(c)
-        if err != nil {
+        user, err := requireRole(c, table.RoleAdmin)
+        if user == nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Failed to claim JWT Token.",
@@ -924,9 +1097,8 @@ func appHandleRegisterAdmin(backend *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-        admin := claims["admin"].(float64)
 
-        if admin != 1 {
+        if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Invalid credentials to acces this api.",
@@ -1001,9 +1173,18 @@ func appHandleRegisterAdmin(backend *Backend, route fiber.Router) {
             })
         }
 
-        useMe := 1
+        useMe := table.RoleAdmin
         if body.UserRole != nil {
-            useMe = *body.UserRole
+            useMe = table.UserRoleEnum(*body.UserRole)
+        }
+
+        if !canGrantRole(user.UserRole, useMe) {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid role or not allowed to give that role.",
+                "error_code": 10,
+                "data": nil,
+            })
         }
 
         newUser := table.User {
@@ -1038,9 +1219,9 @@ func appHandleRegisterAdmin(backend *Backend, route fiber.Router) {
 // NOTE: Call this to logout (eg. delete the cookie)
 // POST : api/c/logout
 // POST : api/protected/logout
-func appHandleUserLogOut(_ *Backend, route fiber.Router) {
+func appHandleUserLogOut(backend *Backend, route fiber.Router) {
     route.Post("logout", func (c *fiber.Ctx) error {
-        _, err := GetJWT(c)
+        session, err := currentSession(c)
         if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
@@ -1049,16 +1230,18 @@ func appHandleUserLogOut(_ *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-	c.ClearCookie("jwt")
 
-        c.Cookie(&fiber.Cookie{
-            Name:     "jwt",
-            Value:    "deleted",
-            HTTPOnly: true,
-            Secure:   false,
-            SameSite: "Lax",
-            Expires:  time.Now().Add(-3 * time.Hour),
-        })
+        _, err = revokeSession(backend, session.UserId, session.ID)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to revoke the session, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        clearAuthCookies(backend, c)
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
@@ -1068,7 +1251,7 @@ func appHandleUserLogOut(_ *Backend, route fiber.Router) {
         })
     })
     route.Get("logout", func (c *fiber.Ctx) error {
-        _, err := GetJWT(c)
+        session, err := currentSession(c)
         if err != nil {
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
@@ -1077,16 +1260,18 @@ func appHandleUserLogOut(_ *Backend, route fiber.Router) {
                 "data": nil,
             })
         }
-	c.ClearCookie("jwt")
 
-        c.Cookie(&fiber.Cookie{
-            Name:     "jwt",
-            Value:    "deleted",
-            HTTPOnly: true,
-            Secure:   false,
-            SameSite: "Lax",
-            Expires:  time.Now().Add(-3 * time.Hour),
-        })
+        _, err = revokeSession(backend, session.UserId, session.ID)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": fmt.Sprintf("Failed to revoke the session, %v", err),
+                "error_code": 2,
+                "data": nil,
+            })
+        }
+
+        clearAuthCookies(backend, c)
 
         return c.Status(fiber.StatusOK).JSON(fiber.Map{
             "success": true,
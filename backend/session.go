@@ -0,0 +1,198 @@
+package main
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "time"
+    "webrpl/table"
+
+    "github.com/gofiber/fiber/v2"
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const refreshCookiePath = "/api/token"
+
+func randomToken(n int) (string, error) {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// NOTE: `sid` is what tie the access token to the session row, a token
+//       without it (the old 72 hour one) will not pass sessionCheck.
+//       `admin` is only kept for the client, the backend use authz.go.
+func signAccessToken(backend *Backend, user *table.User, session *table.Session) (string, time.Time, error) {
+    expire := time.Now().Add(backend.config.Auth.AccessTTL)
+    claims := jwt.MapClaims{
+        "email": user.UserEmail,
+        "admin": user.UserRole,
+        "sid":   session.SessionKey,
+        "exp":   expire.Unix(),
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+    t, err := token.SignedString([]byte(backend.pass))
+    return t, expire, err
+}
+
+// Create a new session for the user, return the session and the plain refresh token.
+func createSession(backend *Backend, c *fiber.Ctx, user *table.User) (*table.Session, string, error) {
+    key, err := randomToken(24)
+    if err != nil {
+        return nil, "", err
+    }
+    refresh, err := randomToken(32)
+    if err != nil {
+        return nil, "", err
+    }
+
+    now := time.Now()
+    session := table.Session{
+        UserId:          user.ID,
+        SessionKey:      key,
+        SessionRefresh:  hashToken(refresh),
+        SessionAgent:    c.Get(fiber.HeaderUserAgent),
+        SessionIP:       c.IP(),
+        SessionExpire:   now.Add(backend.config.Auth.RefreshTTL),
+        SessionLastUsed: now,
+    }
+    if err := backend.db.Create(&session).Error; err != nil {
+        return nil, "", err
+    }
+    return &session, refresh, nil
+}
+
+func setAuthCookies(backend *Backend, c *fiber.Ctx, access string, accessExpire time.Time, refresh string, refreshExpire time.Time) {
+    c.Cookie(&fiber.Cookie{
+        Name:     "jwt",
+        Value:    access,
+        HTTPOnly: true,
+        Secure:   backend.config.SecureCookie(),
+        SameSite: "Lax",
+        Expires:  accessExpire,
+    })
+    c.Cookie(&fiber.Cookie{
+        Name:     "refresh",
+        Value:    refresh,
+        Path:     refreshCookiePath,
+        HTTPOnly: true,
+        Secure:   backend.config.SecureCookie(),
+        SameSite: "Strict",
+        Expires:  refreshExpire,
+    })
+}
+
+func clearAuthCookies(backend *Backend, c *fiber.Ctx) {
+    c.Cookie(&fiber.Cookie{
+        Name:     "jwt",
+        Value:    "deleted",
+        HTTPOnly: true,
+        Secure:   backend.config.SecureCookie(),
+        SameSite: "Lax",
+        Expires:  time.Now().Add(-3 * time.Hour),
+    })
+    c.Cookie(&fiber.Cookie{
+        Name:     "refresh",
+        Value:    "deleted",
+        Path:     refreshCookiePath,
+        HTTPOnly: true,
+        Secure:   backend.config.SecureCookie(),
+        SameSite: "Strict",
+        Expires:  time.Now().Add(-3 * time.Hour),
+    })
+}
+
+func findActiveSession(backend *Backend, key string) (*table.Session, error) {
+    var session table.Session
+    res := backend.db.Preload("User").
+        Where("session_key = ? AND session_revoked_at IS NULL AND session_expire > ?", key, time.Now()).
+        First(&session)
+    if res.Error != nil {
+        return nil, res.Error
+    }
+    return &session, nil
+}
+
+// NOTE: Used as the jwtware SuccessHandler, the signature is already checked
+//       at this point so only the session row is looked at. Error code -1 is
+//       used so it dont clash with the code of the handler behind it.
+func sessionCheck(backend *Backend) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        claims, err := GetJWT(c)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Invalid JWT token.",
+                "error_code": -1,
+                "data": nil,
+            })
+        }
+
+        sid, ok := claims["sid"].(string)
+        if !ok || sid == "" {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Token has no session, please login again.",
+                "error_code": -1,
+                "data": nil,
+            })
+        }
+
+        session, err := findActiveSession(backend, sid)
+        if err != nil || session.User.ID == 0 {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Session expired or revoked, please login again.",
+                "error_code": -1,
+                "data": nil,
+            })
+        }
+
+        // NOTE: Dont write to the db on every request, a minute is precise enough.
+        if time.Since(session.SessionLastUsed) > time.Minute {
+            backend.db.Model(&table.Session{}).
+                Where("id = ?", session.ID).
+                Update("session_last_used", time.Now())
+        }
+
+        c.Locals("session", session)
+        return c.Next()
+    }
+}
+
+// Only valid behind the protected or cookieJWT group.
+func currentSession(c *fiber.Ctx) (*table.Session, error) {
+    session, ok := c.Locals("session").(*table.Session)
+    if !ok || session == nil {
+        return nil, errors.New("no session on this request")
+    }
+    return session, nil
+}
+
+func revokeSession(backend *Backend, userId int, sessionId int) (int64, error) {
+    res := backend.db.Model(&table.Session{}).
+        Where("id = ? AND user_id = ? AND session_revoked_at IS NULL", sessionId, userId).
+        Update("session_revoked_at", time.Now())
+    return res.RowsAffected, res.Error
+}
+
+// Revoke every session of the user, exceptId is kept alive when > 0.
+func revokeUserSessions(backend *Backend, userId int, exceptId int) (int64, error) {
+    query := backend.db.Model(&table.Session{}).
+        Where("user_id = ? AND session_revoked_at IS NULL", userId)
+    if exceptId > 0 {
+        query = query.Where("id <> ?", exceptId)
+    }
+    res := query.Update("session_revoked_at", time.Now())
+    return res.RowsAffected, res.Error
+}
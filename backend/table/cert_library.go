@@ -0,0 +1,19 @@
+package table
+
+import (
+    "gorm.io/gorm"
+)
+
+// NOTE: A shared template any event can start from. CertLPath is relative to
+//       the hidden dir (not served), the bg.png is next to it and the html
+//       point to it with `@@`.
+type CertLibrary struct {
+    gorm.Model
+    ID            int    `gorm:"primaryKey"`
+    CertLName     string `gorm:"column:certl_name;size:100;uniqueIndex"`
+    CertLDesc     string `gorm:"column:certl_desc"`
+    CertLPath     string `gorm:"column:certl_path"`
+    CertLAuthorId *int   `gorm:"column:certl_author_id"`
+
+    Author *User `gorm:"foreignKey:CertLAuthorId"`
+}
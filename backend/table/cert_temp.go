@@ -4,11 +4,14 @@ import (
     "gorm.io/gorm"
 )
 
+// NOTE: CertTemplate is the path of the active version, CertActiveVersion the
+//       row it come from, nil for a template made before the version.
 type CertTemplate struct {
     gorm.Model
-    ID           int    `gorm:"primaryKey"`
-    CertTemplate string `gorm:"column:cert_template"`
-    EventId      int    `gorm:"column:event_id"`
+    ID                int    `gorm:"primaryKey"`
+    CertTemplate      string `gorm:"column:cert_template"`
+    EventId           int    `gorm:"column:event_id"`
+    CertActiveVersion *int   `gorm:"column:cert_active_version"`
 
     Event   Event  `gorm:"foreignKey:EventId"`
 }
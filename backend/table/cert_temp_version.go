@@ -0,0 +1,20 @@
+package table
+
+import (
+    "gorm.io/gorm"
+)
+
+// NOTE: One row per save of the editor, the file are never overwritten so any
+//       of them can be made active again. CertVPath is relative to the static
+//       dir like CertTemplate.CertTemplate, the bg.png is next to it.
+type CertTemplateVersion struct {
+    gorm.Model
+    ID              int    `gorm:"primaryKey"`
+    CertVTemplateId int    `gorm:"column:certv_template_id;uniqueIndex:idx_cert_template_versions_number"`
+    CertVNumber     int    `gorm:"column:certv_number;uniqueIndex:idx_cert_template_versions_number"`
+    CertVPath       string `gorm:"column:certv_path"`
+    CertVHash       string `gorm:"column:certv_hash;size:64"`
+    CertVAuthorId   *int   `gorm:"column:certv_author_id"`
+
+    Author *User `gorm:"foreignKey:CertVAuthorId"`
+}
@@ -0,0 +1,30 @@
+package table
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// NOTE: One row per issue, a reissue revoke the current row and add a new one
+//       with the next revision. The unique (participant, revision) is what stop
+//       two request from issuing the same certificate twice.
+type Certificate struct {
+    gorm.Model
+    ID                    int        `gorm:"primaryKey"`
+    CertParticipantId     int        `gorm:"column:cert_participant_id;uniqueIndex:idx_certificates_participant_revision"`
+    CertRevision          int        `gorm:"column:cert_revision;uniqueIndex:idx_certificates_participant_revision"`
+    CertTemplateId        *int       `gorm:"column:cert_template_id"`
+    // The version that was active when issued, nil when the template had none
+    // (a path set by hand) or there was no template yet.
+    CertTemplateVersionId *int       `gorm:"column:cert_template_version_id;index"`
+    CertSerial            string     `gorm:"column:cert_serial;size:32;uniqueIndex"`
+    CertIssuedAt          time.Time  `gorm:"column:cert_issued_at"`
+    // What the verify code sign, the CertIssuedAt of the first revision.
+    CertSignedAt          time.Time  `gorm:"column:cert_signed_at"`
+    CertRevokedAt         *time.Time `gorm:"column:cert_revoked_at"`
+    CertRevokeReason      string     `gorm:"column:cert_revoke_reason"`
+
+    Participant     EventParticipant     `gorm:"foreignKey:CertParticipantId"`
+    TemplateVersion *CertTemplateVersion `gorm:"foreignKey:CertTemplateVersionId"`
+}
@@ -0,0 +1,35 @@
+package table
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+type EmailStatusEnum string
+
+const (
+    EmailPending EmailStatusEnum = "pending"
+    EmailSending EmailStatusEnum = "sending"
+    EmailSent    EmailStatusEnum = "sent"
+    EmailFailed  EmailStatusEnum = "failed"
+)
+
+// NOTE: EmailTo is comma separated, EmailAttach is the json of []MailAttachment.
+//       The body is never sent back by the api. EmailSensitive is for the
+//       mail that carry a secret (the otp code), the body is blanked once it
+//       is sent or failed for good.
+type EmailOutbox struct {
+    gorm.Model
+    ID             int             `gorm:"primaryKey"`
+    EmailTo        string          `gorm:"column:email_to"`
+    EmailSubject   string          `gorm:"column:email_subject"`
+    EmailText      string          `gorm:"column:email_text" json:"-"`
+    EmailHTML      string          `gorm:"column:email_html" json:"-"`
+    EmailAttach    string          `gorm:"column:email_attach" json:"-"`
+    EmailStatus    EmailStatusEnum `gorm:"column:email_status;size:16;index"`
+    EmailAttempts  int             `gorm:"column:email_attempts"`
+    EmailLastError string          `gorm:"column:email_last_error"`
+    EmailNextTry   time.Time       `gorm:"column:email_next_try"`
+    EmailSentAt    *time.Time      `gorm:"column:email_sent_at"`
+    EmailSensitive bool            `gorm:"column:email_sensitive;default:false"`
+}
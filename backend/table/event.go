@@ -19,11 +19,19 @@ type Event struct {
     EventName    string      `gorm:"column:event_name"`
     EventImg     string      `gorm:"column:event_img"`
     EventMax     int         `gorm:"column:event_max"`
-    EventDStart  time.Time   `gorm:"column:event_dstart;type:datetime"`
-    EventDEnd    time.Time   `gorm:"column:event_dend;type:datetime"`
+    EventDStart  time.Time   `gorm:"column:event_dstart"`
+    EventDEnd    time.Time   `gorm:"column:event_dend"`
     EventLink    string      `gorm:"column:event_link"`
     EventSpeaker string      `gorm:"column:event_speaker"`
     EventAtt     AttTypeEnum `gorm:"column:event_att"`
+    // NOTE: Nil mean no limit on that side. Without EventCancelUntil the
+    //       participant can cancel until the event start.
+    EventRegOpen     *time.Time `gorm:"column:event_reg_open"`
+    EventRegClose    *time.Time `gorm:"column:event_reg_close"`
+    EventCancelUntil *time.Time `gorm:"column:event_cancel_until"`
+    EventForm        []EventFormField `gorm:"column:event_form;type:text;serializer:json"`
+    // NOTE: A normal registration is pending until the committee approve it.
+    EventNeedApproval bool `gorm:"column:event_need_approval;default:false"`
 
     EventMaterials    []EventMaterial    `gorm:"foreignKey:EventId"`
     EventParticipants []EventParticipant `gorm:"foreignKey:EventId"`
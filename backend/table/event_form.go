@@ -0,0 +1,22 @@
+package table
+
+type FormFieldTypeEnum string
+
+const (
+    FormText     FormFieldTypeEnum = "text"
+    FormTextarea FormFieldTypeEnum = "textarea"
+    FormNumber   FormFieldTypeEnum = "number"
+    FormSelect   FormFieldTypeEnum = "select"
+    // NOTE: A required checkbox must be checked, for consent.
+    FormCheckbox FormFieldTypeEnum = "checkbox"
+)
+
+// One question of the registration form of an event, the answer is saved
+// under Name on EventParticipant.EventPAnswers.
+type EventFormField struct {
+    Name     string            `json:"name"`
+    Label    string            `json:"label"`
+    Type     FormFieldTypeEnum `json:"type"`
+    Required bool              `json:"required"`
+    Options  []string          `json:"options,omitempty"`
+}
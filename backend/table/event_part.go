@@ -1,6 +1,7 @@
 package table
 
 import (
+    "time"
     "gorm.io/gorm"
 )
 
@@ -11,14 +12,39 @@ const (
     CommitteeU UserEventRoleEnum = "committee"
 )
 
+type ParticipantStatusEnum string
+
+const (
+    RegisteredP ParticipantStatusEnum = "registered"
+    WaitlistedP ParticipantStatusEnum = "waitlisted"
+    // NOTE: For an event with EventNeedApproval, neither take a seat or can
+    //       use the code.
+    PendingP    ParticipantStatusEnum = "pending"
+    RejectedP   ParticipantStatusEnum = "rejected"
+)
+
 type EventParticipant struct {
     gorm.Model
     ID           int               `gorm:"primaryKey"`
-    EventId      int               `gorm:"column:event_id"`
-    UserId       int               `gorm:"column:user_id"`
+    // NOTE: Unique with the soft deleted row too, registering again revive
+    //       the old row instead.
+    EventId      int               `gorm:"column:event_id;uniqueIndex:idx_event_participants_event_user"`
+    UserId       int               `gorm:"column:user_id;uniqueIndex:idx_event_participants_event_user"`
     EventPRole   UserEventRoleEnum `gorm:"column:eventp_role"`
     EventPCome   bool              `gorm:"column:eventp_come"`
-    EventPCode   string            `gorm:"column:eventp_code"`
+    EventPCode   string            `gorm:"column:eventp_code;size:64;uniqueIndex"`
+    // NOTE: The base64 code from before 0007, only kept so the certificate
+    //       link already sent still work.
+    EventPLegacyCode string        `gorm:"column:eventp_legacy_code;size:255;index" json:"-"`
+    EventPRemindedAt *time.Time    `gorm:"column:eventp_reminded_at"`
+    EventPCertSentAt *time.Time    `gorm:"column:eventp_cert_sent_at"`
+    // NOTE: Only the registered one count toward EventMax, the waitlisted
+    //       one wait on EventPWaitPos (1 is the next to be promoted).
+    EventPStatus     ParticipantStatusEnum `gorm:"column:eventp_status;size:20;default:registered"`
+    EventPWaitPos    *int          `gorm:"column:eventp_wait_pos"`
+    // NOTE: Answer of the event form by field name, always a string (a
+    //       checkbox is `true` or `false`) so it go straight to a csv.
+    EventPAnswers    map[string]string `gorm:"column:eventp_answers;type:text;serializer:json"`
 
     Event        Event  `gorm:"foreignKey:EventId"`
     User         User   `gorm:"foreignKey:UserId"`
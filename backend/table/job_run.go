@@ -0,0 +1,29 @@
+package table
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+type JobStatusEnum string
+
+const (
+    JobRunning JobStatusEnum = "running"
+    JobOK      JobStatusEnum = "ok"
+    JobFailed  JobStatusEnum = "failed"
+)
+
+// NOTE: One row per job, overwritten on every run. JobMessage is what the job
+//       returned on success or the error on failure.
+type JobRun struct {
+    gorm.Model
+    ID           int           `gorm:"primaryKey"`
+    JobName      string        `gorm:"column:job_name;size:64;uniqueIndex"`
+    JobStatus    JobStatusEnum `gorm:"column:job_status;size:16"`
+    JobMessage   string        `gorm:"column:job_message"`
+    JobManual    bool          `gorm:"column:job_manual"`
+    JobStartedAt *time.Time    `gorm:"column:job_started_at"`
+    JobEndedAt   *time.Time    `gorm:"column:job_ended_at"`
+    JobRunCount  int           `gorm:"column:job_run_count"`
+    JobFailCount int           `gorm:"column:job_fail_count"`
+}
@@ -0,0 +1,25 @@
+package table
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+type LockoutKindEnum string
+
+const (
+    LockoutLogin LockoutKindEnum = "login"
+    LockoutOTP   LockoutKindEnum = "otp"
+)
+
+// NOTE: One row every time an account or otp get locked, only for the admin
+//       to look at. The actual lock live on User.UserLockedUntil and OTP.OtpLocked.
+type Lockout struct {
+    gorm.Model
+    ID           int             `gorm:"primaryKey"`
+    LockKind     LockoutKindEnum `gorm:"column:lock_kind;size:16;index"`
+    LockEmail    string          `gorm:"column:lock_email;size:255;index"`
+    LockIP       string          `gorm:"column:lock_ip"`
+    LockAttempts int             `gorm:"column:lock_attempts"`
+    LockUntil    *time.Time      `gorm:"column:lock_until"`
+}
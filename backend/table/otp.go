@@ -5,13 +5,29 @@ import (
     "gorm.io/gorm"
 )
 
+// NOTE: A code is only good for the purpose it was made for, a register code
+//       cant be used to reset the password.
+type OTPPurposeEnum string
+
+const (
+    OTPRegister      OTPPurposeEnum = "register"
+    OTPResetPassword OTPPurposeEnum = "reset-password"
+    OTPEmailChange   OTPPurposeEnum = "email-change"
+    OTPLogin2FA      OTPPurposeEnum = "login-2fa"
+)
+
 // Change UserId to UserEmail so it work...
 // Added time TimeCreated
+// OtpLocked is set after too many wrong code, only a new code unlock it.
+// OtpCode is the hmac of the code (see hashOTP), the plain one only go to the email.
 type OTP struct {
     gorm.Model
-    ID          int       `gorm:"primaryKey"`
-    UserEmail   string    `gorm:"column:user_email"`
-    OtpCode     string    `gorm:"column:otp_code"`
-    TimeCreated time.Time `gorm:"column:time_created"`
-    Used        bool      `gorm:"column:used"`
+    ID          int            `gorm:"primaryKey"`
+    UserEmail   string         `gorm:"column:user_email"`
+    OtpPurpose  OTPPurposeEnum `gorm:"column:otp_purpose;size:32;index"`
+    OtpCode     string         `gorm:"column:otp_code" json:"-"`
+    TimeCreated time.Time      `gorm:"column:time_created"`
+    Used        bool           `gorm:"column:used"`
+    OtpAttempts int            `gorm:"column:otp_attempts"`
+    OtpLocked   bool           `gorm:"column:otp_locked"`
 }
@@ -0,0 +1,23 @@
+package table
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+// NOTE: SessionKey is the `sid` claim on the access token,
+//       SessionRefresh is the sha256 of the refresh token (never the token itself).
+type Session struct {
+    gorm.Model
+    ID               int        `gorm:"primaryKey"`
+    UserId           int        `gorm:"column:user_id;index"`
+    SessionKey       string     `gorm:"column:session_key;size:64;uniqueIndex" json:"-"`
+    SessionRefresh   string     `gorm:"column:session_refresh;size:64;uniqueIndex" json:"-"`
+    SessionAgent     string     `gorm:"column:session_agent"`
+    SessionIP        string     `gorm:"column:session_ip"`
+    SessionExpire    time.Time  `gorm:"column:session_expire"`
+    SessionLastUsed  time.Time  `gorm:"column:session_last_used"`
+    SessionRevokedAt *time.Time `gorm:"column:session_revoked_at"`
+
+    User             User       `gorm:"foreignKey:UserId" json:"-"`
+}
@@ -5,7 +5,17 @@ import (
     "gorm.io/gorm"
 )
 
-// IF 1 then its an ADMIN other is NORMAL USER
+// NOTE: 1 stay as ADMIN so the old data and the frontend still work,
+//       the number is not the rank, use roleAtLeast on the backend.
+type UserRoleEnum int
+
+const (
+    RoleUser       UserRoleEnum = 0
+    RoleAdmin      UserRoleEnum = 1
+    RoleOrganizer  UserRoleEnum = 2
+    RoleSuperAdmin UserRoleEnum = 3
+)
+
 type User struct {
     gorm.Model
     ID             int       `gorm:"primaryKey"`
@@ -13,9 +23,11 @@ type User struct {
     UserPassword   string    `gorm:"column:user_password" json:"-"`
     UserEmail      string    `gorm:"column:user_email"`
     UserInstance   string    `gorm:"column:user_instance"`
-    UserRole       int       `gorm:"column:user_role"`
+    UserRole       UserRoleEnum `gorm:"column:user_role"`
     UserPicture    string    `gorm:"column:user_picture"`
-    UserCreatedAt  time.Time `gorm:"column:user_created_at;type:datetime"`
+    UserCreatedAt  time.Time `gorm:"column:user_created_at"`
+    UserFailedLogin int        `gorm:"column:user_failed_login" json:"-"`
+    UserLockedUntil *time.Time `gorm:"column:user_locked_until"`
 
     EventParticipants []EventParticipant `gorm:"foreignKey:UserId"`
 }
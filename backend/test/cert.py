@@ -20,3 +20,134 @@ if __name__ == "__main__":
         desc="Test accessing the editor."
     )
     test2.test(0)
+
+    test3 = TestApi.TestApi(
+        "certificate/not-a-real-code.pdf",
+        method="get",
+        desc="Test the pdf certificate of an unknown code."
+    )
+    test3.test(4)
+
+    test4 = TestApi.TestApi(
+        "certificate-verify/1.forged",
+        method="get",
+        desc="Test verifying a forged certificate code."
+    )
+    test4.test(1)
+
+    test5 = TestApi.TestApi(
+        "certificate-verify/1.forged/qr.png?size=1",
+        method="get",
+        desc="Test the qr of a certificate with an invalid size."
+    )
+    test5.test(3)
+
+    test6 = TestApi.TestApi(
+        "protected/certificate-list?status=active",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test listing the issued certificate."
+    )
+    test6.test(0)
+
+    test7 = TestApi.TestApi(
+        "protected/certificate-revoke",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        payload= { "id": 1 },
+        method="post",
+        desc="Test revoking a certificate without reason."
+    )
+    test7.test(3)
+
+    test8 = TestApi.TestApi(
+        "protected/certificate-reissue",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        payload= { "id": 999999 },
+        method="post",
+        desc="Test reissuing a certificate that does not exist."
+    )
+    test8.test(5)
+
+    test9 = TestApi.TestApi(
+        "protected/certificate-export?event_id=1&format=doc",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test exporting the certificate with an unknown format."
+    )
+    test9.test(2)
+
+    test10 = TestApi.TestApi(
+        "protected/certificate-email",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        payload= { "event_id": 999999 },
+        method="post",
+        desc="Test mailing the certificate of an event that does not exist."
+    )
+    test10.test(4)
+
+    test11 = TestApi.TestApi(
+        "protected/cert-template-versions?event_id=999999",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test listing the template version of an event without template."
+    )
+    test11.test(4)
+
+    test12 = TestApi.TestApi(
+        "protected/cert-template-preview?event_id=1&format=doc",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test previewing a template with an unknown format."
+    )
+    test12.test(1)
+
+    test13 = TestApi.TestApi(
+        "protected/cert-template-placeholders",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test listing the certificate template placeholders."
+    )
+    test13.test(0)
+
+    test14 = TestApi.TestApi(
+        "protected/-cert-editor-upload-html",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        payload= { "event_id": "7", "data": "e3sgLk5vcGUgfX0=" },
+        method="post",
+        desc="Test saving a certificate template with an unknown placeholder."
+    )
+    test14.test(12)
+
+    test15 = TestApi.TestApi(
+        "protected/cert-library",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test listing the certificate template library."
+    )
+    test15.test(0)
+
+    test16 = TestApi.TestApi(
+        "protected/cert-library-use",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        payload= { "id": 999999, "event_id": 1 },
+        method="post",
+        desc="Test using a library template that does not exist."
+    )
+    test16.test(4)
+
+    test17 = TestApi.TestApi(
+        "protected/engine-stats",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        method="get",
+        desc="Test admin reading the template engine cache stats."
+    )
+    test17.test(0)
+
+    test18 = TestApi.TestApi(
+        "protected/-cert-editor-upload-html",
+        headers={ "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" },
+        payload= { "event_id": "7", "data": "e3sgdGVtcGxhdGUgIm5vcGUiIC4gfX0=" },
+        method="post",
+        desc="Test saving a certificate template that use a partial that does not exist."
+    )
+    test18.test(12)
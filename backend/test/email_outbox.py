@@ -0,0 +1,32 @@
+import TestApi
+import utils
+
+if __name__ == "__main__":
+    admin_token = utils.login("admin@wowadmin.com", "secret")
+    headers = { "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" }
+
+    test1 = TestApi.TestApi(
+        "protected/email-outbox-list",
+        method="GET",
+        headers=headers,
+        desc="Test listing pending and failed email on the outbox, it should return error_code 0."
+    )
+    test1.test(0)
+
+    test2 = TestApi.TestApi(
+        "protected/email-outbox-list?status=sent",
+        method="GET",
+        headers=headers,
+        desc="Test listing sent email on the outbox, it should return error_code 0."
+    )
+    test2.test(0)
+
+    test3 = TestApi.TestApi(
+        "protected/email-outbox-requeue",
+        method="POST",
+        headers=headers,
+        payload={ "ids": [] },
+        desc="Test requeue without any id, it should return error_code 4."
+    )
+    test3.test(4)
+
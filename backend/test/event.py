@@ -120,3 +120,52 @@ if __name__ == "__main__":
         desc="Test get total webinar count, should return error_code 0.",
     )
     get_total_webinar_count_success.test(0)
+
+    # 8. Test adding a webinar whose registration close before it open
+    add_webinar_bad_window = debug(
+        "protected/event-register",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}",
+        },
+        payload={
+            "desc": "Window Webinar",
+            "name": "Window Webinar",
+            "dstart": "2030-10-01T10:00:00Z",
+            "dend": "2030-10-01T11:00:00Z",
+            "link": "https://example.com/webinar",
+            "speaker": "Window Speaker",
+            "att": "online",
+            "img": "https://example.com/image.jpg",
+            "max" : 10,
+            "reg_open": "2030-09-20T00:00:00Z",
+            "reg_close": "2030-09-10T00:00:00Z",
+        },
+        desc="Test add webinar with registration closing before it open, should return error_code 9.",
+    )
+    add_webinar_bad_window.test(9)
+
+    # 9. Test adding a webinar with a select field that has no option
+    add_webinar_bad_form = debug(
+        "protected/event-register",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}",
+        },
+        payload={
+            "desc": "Form Webinar",
+            "name": "Form Webinar",
+            "dstart": "2030-10-01T10:00:00Z",
+            "dend": "2030-10-01T11:00:00Z",
+            "link": "https://example.com/webinar",
+            "speaker": "Form Speaker",
+            "att": "online",
+            "img": "https://example.com/image.jpg",
+            "max" : 10,
+            "form": [
+                { "name": "tshirt", "label": "T-Shirt Size", "type": "select", "required": True },
+            ],
+        },
+        desc="Test add webinar with a select field without options, should return error_code 10.",
+    )
+    add_webinar_bad_form.test(10)
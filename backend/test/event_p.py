@@ -130,3 +130,99 @@ if __name__ == "__main__":
         desc="Test get absence status of a participant in a webinar (Online), should return error_code 0.",
     )
     get_absence_status_online_success.test(0)
+
+    # 10. Test get the waitlist of a webinar
+    get_waitlist_success = debug(
+        "protected/event-participate-waitlist?event_id=6",
+        method="GET",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        desc="Test get the waitlist of a webinar, should return error_code 0.",
+    )
+    get_waitlist_success.test(0)
+
+    # 11. Test promoting a user that is not on the waitlist
+    promote_not_waitlisted = debug(
+        "protected/event-participate-waitlist-promote",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        payload={
+            "event_id": 6,
+            "email": "nobody@example.com",
+        },
+        desc="Test promote a user that is not on the waitlist, should return error_code 4.",
+    )
+    promote_not_waitlisted.test(4)
+
+    # 12. Test reordering the waitlist without any email
+    reorder_waitlist_empty = debug(
+        "protected/event-participate-waitlist-reorder",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        payload={
+            "event_id": 6,
+            "emails": [],
+        },
+        desc="Test reorder the waitlist without any email, should return error_code 1.",
+    )
+    reorder_waitlist_empty.test(1)
+
+    # 13. Test cancelling a registration that does not exist
+    cancel_not_registered = debug(
+        "protected/event-participate-cancel",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        payload={
+            "event_id": 999999,
+        },
+        desc="Test cancel a registration that does not exist, should return error_code 3.",
+    )
+    cancel_not_registered.test(3)
+
+    # 14. Test exporting the participant of an event that does not exist
+    export_unknown_event = debug(
+        "protected/event-participate-export?event_id=999999",
+        method="GET",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        desc="Test export the participant of an unknown event, should return error_code 4.",
+    )
+    export_unknown_event.test(4)
+
+    # 15. Test approving without any email
+    approve_empty = debug(
+        "protected/event-participate-approve",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        payload={
+            "event_id": 6,
+            "emails": [],
+        },
+        desc="Test approve registration without any email, should return error_code 1.",
+    )
+    approve_empty.test(1)
+
+    # 16. Test rejecting a user that is not pending
+    reject_not_pending = debug(
+        "protected/event-participate-reject",
+        method="POST",
+        headers={
+            "Authorization": f"Bearer {admin_token}"
+        },
+        payload={
+            "event_id": 6,
+            "emails": ["nobody@example.com"],
+        },
+        desc="Test reject a user that is not waiting for approval, should return error_code 4.",
+    )
+    reject_not_pending.test(4)
@@ -0,0 +1,90 @@
+import uuid
+from concurrent.futures import ThreadPoolExecutor
+
+import requests
+import TestApi
+import utils
+
+debug = TestApi.TestApi
+
+# NOTE : Hammer event-participate-register from many thread at once, the
+# event must never have more registered participant than its max and a user
+# must never be registered twice.
+
+MAX = 3
+USERS = 12
+THREADS = 12
+
+def report(ok: bool, desc: str):
+    status = "PASSED" if ok else "FAIL"
+    print(f"[{status}]: {desc}\n")
+
+if __name__ == "__main__":
+    admin_token = utils.login("admin@wowadmin.com", "secret")
+    headers = { "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" }
+    run = uuid.uuid4().hex[:8]
+
+    event = debug(
+        "protected/event-register",
+        method="POST",
+        headers=headers,
+        payload={
+            "desc": "Race Webinar",
+            "name": f"Race Webinar {run}",
+            "dstart": "2030-10-01T10:00:00Z",
+            "dend": "2030-10-01T11:00:00Z",
+            "link": "https://example.com/webinar",
+            "speaker": "Race Speaker",
+            "att": "online",
+            "img": "https://example.com/image.jpg",
+            "max": MAX,
+        },
+    ).send() or {}
+    event_id = (event.get("data") or {}).get("id")
+    report(event_id is not None, "Test creating the event for the race, should return its id.")
+
+    emails = []
+    for i in range(USERS + 1):
+        email = f"race-{run}-{i}@example.com"
+        created = debug(
+            "protected/register-admin",
+            method="POST",
+            headers=headers,
+            payload={ "email": email, "name": f"Race {i}", "pass": "secret-race", "instance": "Race" },
+        ).send() or {}
+        if created.get("error_code") == 0:
+            emails.append(email)
+    report(len(emails) == USERS + 1, "Test creating the user for the race.")
+    twice = emails.pop()
+
+    def register(email: str) -> int:
+        response = requests.post(
+            "http://localhost:3000/api/protected/event-participate-register",
+            json={ "id": event_id, "role": "normal", "email": email },
+            headers=headers,
+        )
+        return response.json().get("error_code", -1)
+
+    # Every user at once, then the same user many time at once.
+    with ThreadPoolExecutor(max_workers=THREADS) as pool:
+        codes = list(pool.map(register, emails))
+    report(codes.count(0) == USERS, "Test registering many user at once, every one should be registered or waitlisted.")
+
+    with ThreadPoolExecutor(max_workers=THREADS) as pool:
+        codes = list(pool.map(register, [twice] * THREADS))
+    report(codes.count(0) == 1 and codes.count(12) == THREADS - 1, "Test registering the same user many time at once, only one should return error_code 0 and the rest 12.")
+
+    count = debug(
+        f"protected/event-participate-of-event-count?id={event_id}",
+        method="GET",
+        headers=headers,
+    ).send() or {}
+    report(count.get("data") == MAX, f"Test the event is not overbooked, should have exactly {MAX} registered.")
+
+    waitlist = debug(
+        f"protected/event-participate-waitlist?event_id={event_id}",
+        method="GET",
+        headers=headers,
+    ).send() or {}
+    positions = sorted(p.get("EventPWaitPos") for p in (waitlist.get("data") or []))
+    report(positions == list(range(1, USERS - MAX + 2)), "Test the rest are waitlisted with a unique position.")
@@ -0,0 +1,33 @@
+import TestApi
+import utils
+
+if __name__ == "__main__":
+    admin_token = utils.login("admin@wowadmin.com", "secret")
+    admin_headers = { "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" }
+
+    test1 = TestApi.TestApi(
+        "protected/job-list",
+        method="GET",
+        headers=admin_headers,
+        desc="Test admin listing the job, it should return error_code 0."
+    )
+    test1.test(0)
+
+    test2 = TestApi.TestApi(
+        "protected/job-run",
+        method="POST",
+        headers=admin_headers,
+        payload={ "name": "session_cleanup" },
+        desc="Test admin running a job by hand, it should return error_code 0."
+    )
+    test2.test(0)
+
+    test3 = TestApi.TestApi(
+        "protected/job-run",
+        method="POST",
+        headers=admin_headers,
+        payload={ "name": "no_such_job" },
+        desc="Test running a job that dont exist, it should return error_code 4."
+    )
+    test3.test(4)
+
@@ -0,0 +1,95 @@
+import TestApi
+import utils
+
+if __name__ == "__main__":
+    admin_token = utils.login("admin@wowadmin.com", "secret")
+    admin_headers = { "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" }
+
+    TestApi.TestApi(
+        "protected/register-admin",
+        method="POST",
+        headers=admin_headers,
+        payload={ "email": "lockme@example.com", "name": "Lock Me", "pass": "password", "instance": "None", "picture": "", "user_role": 0 }
+    ).send()
+
+    # NOTE: Start from a clean counter in case the last run left it locked.
+    TestApi.TestApi(
+        "protected/user-unlock",
+        method="POST",
+        headers=admin_headers,
+        payload={ "email": "lockme@example.com" }
+    ).send()
+
+    # NOTE: The default max_login_failures is 5.
+    for _ in range(4):
+        TestApi.TestApi(
+            "login",
+            method="POST",
+            payload={ "email": "lockme@example.com", "pass": "wrong-password" }
+        ).send()
+
+    test1 = TestApi.TestApi(
+        "login",
+        method="POST",
+        payload={ "email": "lockme@example.com", "pass": "wrong-password" },
+        desc="Test the 5th wrong password in a row, it should return error_code 9."
+    )
+    test1.test(9)
+
+    test2 = TestApi.TestApi(
+        "login",
+        method="POST",
+        payload={ "email": "lockme@example.com", "pass": "password" },
+        desc="Test the right password on a locked account, it should return error_code 9."
+    )
+    test2.test(9)
+
+    test3 = TestApi.TestApi(
+        "protected/lockout-list?kind=login&email=lockme@example.com",
+        method="GET",
+        headers=admin_headers,
+        desc="Test admin listing the lockout, it should return error_code 0."
+    )
+    test3.test(0)
+
+    test4 = TestApi.TestApi(
+        "protected/user-unlock",
+        method="POST",
+        headers=admin_headers,
+        payload={ "email": "lockme@example.com" },
+        desc="Test admin unlocking the account, it should return error_code 0."
+    )
+    test4.test(0)
+
+    test5 = TestApi.TestApi(
+        "login",
+        method="POST",
+        payload={ "email": "lockme@example.com", "pass": "password" },
+        desc="Test login after the unlock, it should return error_code 0."
+    )
+    test5.test(0)
+
+    user_headers = { "Authorization": f"Bearer {utils.login('lockme@example.com', 'password')}", "Content-Type": "application/json" }
+    test6 = TestApi.TestApi(
+        "protected/lockout-list",
+        method="GET",
+        headers=user_headers,
+        desc="Test normal user listing the lockout, it should return error_code 2."
+    )
+    test6.test(2)
+
+    TestApi.TestApi("gen-otp-for-register?email=lockotp@example.com", method="GET").send()
+    for _ in range(4):
+        TestApi.TestApi(
+            "register",
+            method="POST",
+            payload={ "name": "Lock Otp", "email": "lockotp@example.com", "instance": "None", "pass": "password", "otp_code": "wrong" }
+        ).send()
+
+    test7 = TestApi.TestApi(
+        "register",
+        method="POST",
+        payload={ "name": "Lock Otp", "email": "lockotp@example.com", "instance": "None", "pass": "password", "otp_code": "wrong" },
+        desc="Test the 5th wrong otp code, it should return error_code 12."
+    )
+    test7.test(12)
@@ -11,6 +11,20 @@ if __name__ == "__main__":
     )
     test1.test(0)
 
+    test6 = TestApi.TestApi(
+        "gen-otp-for-register?email=federicomatthewpratamaa@gmail.com&purpose=login-2fa",
+        method="GET",
+        desc="Test the gen OTP with a purpose that cant be requested directly, it should return error_code 6."
+    )
+    test6.test(6)
+
+    test7 = TestApi.TestApi(
+        "gen-otp-for-register?email=federicomatthewpratamaa@gmail.com&purpose=reset-password",
+        method="GET",
+        desc="Test the gen OTP for reset password, it should return error_code 0."
+    )
+    test7.test(0)
+
     # test2 = TestApi.TestApi(
     #     "gen-otp-for-register?email=kuuun",
     #     method="GET",
@@ -0,0 +1,74 @@
+import TestApi
+import utils
+
+if __name__ == "__main__":
+    admin_token = utils.login("admin@wowadmin.com", "secret")
+    admin_headers = { "Authorization": f"Bearer {admin_token}", "Content-Type": "application/json" }
+
+    # NOTE: role 2 is organizer, 0 is normal user.
+    for email, role in (("organizer@example.com", 2), ("plainuser@example.com", 0)):
+        TestApi.TestApi(
+            "protected/register-admin",
+            method="POST",
+            headers=admin_headers,
+            payload={ "email": email, "name": email, "pass": "password", "instance": "None", "picture": "", "user_role": role }
+        ).send()
+
+    organizer_headers = { "Authorization": f"Bearer {utils.login('organizer@example.com', 'password')}", "Content-Type": "application/json" }
+    user_headers = { "Authorization": f"Bearer {utils.login('plainuser@example.com', 'password')}", "Content-Type": "application/json" }
+
+    event = {
+        "desc": "Role Webinar",
+        "name": "Role Webinar",
+        "dstart": "2099-10-01T10:00:00Z",
+        "dend": "2099-10-01T11:00:00Z",
+        "link": "https://example.com/webinar",
+        "speaker": "Test Speaker",
+        "att": "online",
+        "img": "",
+        "max": 100,
+    }
+
+    test1 = TestApi.TestApi(
+        "protected/event-register",
+        method="POST",
+        headers=user_headers,
+        payload=event,
+        desc="Test normal user making an event, it should return error_code 2."
+    )
+    test1.test(2)
+
+    test2 = TestApi.TestApi(
+        "protected/event-register",
+        method="POST",
+        headers=organizer_headers,
+        payload=event,
+        desc="Test organizer making an event, it should return error_code 0."
+    )
+    test2.test(0)
+
+    test3 = TestApi.TestApi(
+        "protected/user-info-all",
+        method="GET",
+        headers=organizer_headers,
+        desc="Test organizer listing every user, it should return error_code 1."
+    )
+    test3.test(1)
+
+    test4 = TestApi.TestApi(
+        "protected/register-admin",
+        method="POST",
+        headers=organizer_headers,
+        payload={ "email": "nope@example.com", "name": "nope", "pass": "password", "user_role": 1 },
+        desc="Test organizer making an admin, it should return error_code 2."
+    )
+    test4.test(2)
+
+    test5 = TestApi.TestApi(
+        "protected/user-edit-admin",
+        method="POST",
+        headers=admin_headers,
+        payload={ "email": "plainuser@example.com", "user_role": 7 },
+        desc="Test giving an unknown role, it should return error_code 9."
+    )
+    test5.test(9)
@@ -0,0 +1,60 @@
+import TestApi
+
+if __name__ == "__main__":
+    login = TestApi.TestApi(
+        "login",
+        method="POST",
+        payload={ "email": "admin@wowadmin.com", "pass": "secret" }
+    ).send() or {}
+    token = login.get("token", "")
+    refresh = login.get("refresh_token", "")
+    headers = { "Authorization": f"Bearer {token}", "Content-Type": "application/json" }
+
+    test1 = TestApi.TestApi(
+        "protected/session-list",
+        method="GET",
+        headers=headers,
+        desc="Test listing the session of the current user, it should return error_code 0."
+    )
+    test1.test(0)
+
+    test2 = TestApi.TestApi(
+        "token/refresh",
+        method="POST",
+        payload={ "refresh_token": refresh },
+        desc="Test refreshing the access token, it should return error_code 0."
+    )
+    test2.test(0)
+
+    test3 = TestApi.TestApi(
+        "token/refresh",
+        method="POST",
+        payload={ "refresh_token": refresh },
+        desc="Test reusing a rotated refresh token, it should return error_code 3."
+    )
+    test3.test(3)
+
+    test4 = TestApi.TestApi(
+        "protected/session-revoke",
+        method="POST",
+        headers=headers,
+        payload={ "id": 0 },
+        desc="Test revoking without a session id, it should return error_code 3."
+    )
+    test4.test(3)
+
+    test5 = TestApi.TestApi(
+        "protected/logout",
+        method="POST",
+        headers=headers,
+        desc="Test logging out the current session, it should return error_code 0."
+    )
+    test5.test(0)
+
+    test6 = TestApi.TestApi(
+        "protected/session-list",
+        method="GET",
+        headers=headers,
+        desc="Test using the token of a revoked session, it should return error_code -1."
+    )
+    test6.test(-1)
@@ -8,6 +8,7 @@
 
 import { BaseResponse, RegisterData, LoginData } from "./interface.ts";
 import { API_URL } from "@/api/endpoint";
+import { saveSession } from "@/api/session";
 
 // Fungsi untuk menghubungkan ke API
 export const auth = {
@@ -44,7 +45,7 @@ export const auth = {
       const result = await response.json();
 
       if (result.success && result.token) {
-        localStorage.setItem("token", result.token);
+        saveSession(result);
       }
       return result;
     } catch (error) {
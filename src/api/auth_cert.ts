@@ -5,6 +5,7 @@
 // 1. Create Certificate from Event ✅
 
 import { API_URL } from "@/api/endpoint";
+import { authFetch } from "@/api/session";
 import { BaseResponse } from "./interface";
 
 export const auth_cert = {
@@ -15,7 +16,7 @@ export const auth_cert = {
         event_id: Number(eventID),
       });
 
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/create-new-cert-from-event`,
         {
           method: "POST",
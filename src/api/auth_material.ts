@@ -9,13 +9,14 @@
 
 import { BaseResponse, AddMaterial, EditMaterial } from "./interface";
 import { API_URL } from "@/api/endpoint";
+import { authFetch } from "@/api/session";
 
 export const auth_material = {
   // API untuk menambahkan materi
   add_material: async (data: AddMaterial): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/material-register`,
         {
           method: "POST",
@@ -39,7 +40,7 @@ export const auth_material = {
   get_material: async (event_id: number): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/material-info-of?event_id=${event_id}`,
         {
           method: "GET",
@@ -62,7 +63,7 @@ export const auth_material = {
   delete_material: async (id: number): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/material-del`, {
+      const response = await authFetch(`${API_URL}/api/protected/material-del`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -83,7 +84,7 @@ export const auth_material = {
   edit_material: async (data: EditMaterial): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/material-edit`, {
+      const response = await authFetch(`${API_URL}/api/protected/material-edit`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
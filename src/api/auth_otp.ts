@@ -8,11 +8,14 @@ import { BaseResponse } from "./interface";
 import { API_URL } from "@/api/endpoint";
 
 export const auth_otp = {
-  // API untuk mengirim OTP
-  send_otp: async (email: string): Promise<BaseResponse> => {
+  // API untuk mengirim OTP, purpose "register" atau "reset-password"
+  send_otp: async (
+    email: string,
+    purpose: "register" | "reset-password" = "register",
+  ): Promise<BaseResponse> => {
     try {
       const response = await fetch(
-        `${API_URL}/api/gen-otp-for-register?email=${encodeURIComponent(email)}`,
+        `${API_URL}/api/gen-otp-for-register?email=${encodeURIComponent(email)}&purpose=${purpose}`,
         {
           method: "GET",
           headers: { "Content-Type": "application/json" },
@@ -14,6 +14,7 @@
 // 10. Absence Participant from Event (Online) ✅
 
 import { API_URL } from "@/api/endpoint";
+import { authFetch } from "@/api/session";
 import {
   BaseResponse,
   EventPartisipantAbsence,
@@ -28,7 +29,7 @@ export const auth_participants = {
   ): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-register`,
         {
           method: "POST",
@@ -52,7 +53,7 @@ export const auth_participants = {
   get_participants_by_event: async (eventId: number): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-of-event?event_id=${eventId}`,
         {
           method: "GET",
@@ -77,7 +78,7 @@ export const auth_participants = {
   ): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-edit`,
         {
           method: "POST",
@@ -104,7 +105,7 @@ export const auth_participants = {
   }): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-del`,
         {
           method: "POST",
@@ -136,7 +137,7 @@ export const auth_participants = {
         params.append("email", email);
       }
 
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-info-of?${params}`,
         {
           method: "GET",
@@ -161,7 +162,7 @@ export const auth_participants = {
   ): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-absence`,
         {
           method: "POST",
@@ -185,7 +186,7 @@ export const auth_participants = {
   event_participate_count: async (eventId: number): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-of-event-count?id=${eventId}`,
         {
           method: "GET",
@@ -214,7 +215,7 @@ export const auth_participants = {
       if (userEmail) {
         url += `?email=${encodeURIComponent(userEmail)}`;
       }
-      const response = await fetch(url, {
+      const response = await authFetch(url, {
         method: "GET",
         headers: {
           Authorization: `Bearer ${token}`,
@@ -233,7 +234,7 @@ export const auth_participants = {
   automatic_absence: async (id: number): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-absence-bulk`,
         {
           method: "POST",
@@ -259,7 +260,7 @@ export const auth_participants = {
   }): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-participate-absence-itself`,
         {
           method: "POST",
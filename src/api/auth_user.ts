@@ -16,6 +16,7 @@
 // 12. Get Current Status User Email ✅
 
 import { API_URL } from "@/api/endpoint";
+import { authFetch } from "@/api/session";
 import {
   BaseResponse,
   UserEditData,
@@ -38,7 +39,7 @@ export const auth_user = {
         };
       }
 
-      const response = await fetch(`${API_URL}/api/protected/register-admin`, {
+      const response = await authFetch(`${API_URL}/api/protected/register-admin`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -75,7 +76,7 @@ export const auth_user = {
   user_del_admin: async (data: { id: number }): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-del-admin`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-del-admin`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -97,7 +98,7 @@ export const auth_user = {
   user_edit: async (data: UserEditData): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-edit`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-edit`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -119,7 +120,7 @@ export const auth_user = {
   user_edit_admin: async (data: UserEditData): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-edit-admin`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-edit-admin`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -141,7 +142,7 @@ export const auth_user = {
   user_image: async (data: UserImage): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/user-upload-image`,
         {
           method: "POST",
@@ -167,7 +168,7 @@ export const auth_user = {
   post_update_user_pfp: async (data: string): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-edit`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-edit`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -191,7 +192,7 @@ export const auth_user = {
   get_all_users: async (): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-info-all`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-info-all`, {
         method: "GET",
         headers: {
           Authorization: `Bearer ${token}`,
@@ -211,7 +212,7 @@ export const auth_user = {
   get_user_by_email: async (email: string): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/user-info-of?email=${encodeURIComponent(email)}`,
         {
           method: "GET",
@@ -234,7 +235,7 @@ export const auth_user = {
   get_user_count: async (): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-count`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-count`, {
         method: "GET",
         headers: {
           Authorization: `Bearer ${token}`,
@@ -254,7 +255,7 @@ export const auth_user = {
   get_current_user: async (): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/user-info`, {
+      const response = await authFetch(`${API_URL}/api/protected/user-info`, {
         method: "GET",
         headers: {
           Authorization: `Bearer ${token}`,
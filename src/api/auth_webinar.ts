@@ -11,6 +11,7 @@
 // 7. Get Total Webinar ✅
 
 import { API_URL } from "@/api/endpoint";
+import { authFetch } from "@/api/session";
 import {
   BaseResponse,
   WebinarInput,
@@ -24,7 +25,7 @@ export const auth_webinar = {
   add_webinar: async (data: WebinarInput): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/event-register`, {
+      const response = await authFetch(`${API_URL}/api/protected/event-register`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -50,7 +51,7 @@ export const auth_webinar = {
   post_webinar_image: async (data: WebinarImage): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-upload-image`,
         {
           method: "POST",
@@ -76,7 +77,7 @@ export const auth_webinar = {
   edit_webinar: async (data: WebinarEdit): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/event-edit`, {
+      const response = await authFetch(`${API_URL}/api/protected/event-edit`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -102,7 +103,7 @@ export const auth_webinar = {
   delete_webinar: async (data: { id: number }): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/event-del`, {
+      const response = await authFetch(`${API_URL}/api/protected/event-del`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -123,7 +124,7 @@ export const auth_webinar = {
   get_all_webinar: async (): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/event-info-all`, {
+      const response = await authFetch(`${API_URL}/api/protected/event-info-all`, {
         method: "GET",
         headers: {
           Authorization: `Bearer ${token}`,
@@ -151,7 +152,7 @@ export const auth_webinar = {
   get_webinar_by_id: async (id: number): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(
+      const response = await authFetch(
         `${API_URL}/api/protected/event-info-of?id=${id}`,
         {
           method: "GET",
@@ -175,7 +176,7 @@ export const auth_webinar = {
   get_total_webinar: async (): Promise<BaseResponse> => {
     try {
       const token = localStorage.getItem("token");
-      const response = await fetch(`${API_URL}/api/protected/event-count`, {
+      const response = await authFetch(`${API_URL}/api/protected/event-count`, {
         method: "GET",
         headers: {
           Authorization: `Bearer ${token}`,
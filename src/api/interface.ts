@@ -88,7 +88,7 @@ export interface RegisterAdmin {
   pass: string;
   instance: string;
   picture: string;
-  user_role: number; // 1 for Admin, 0 for User
+  user_role: number; // 0 User, 1 Admin, 2 Organizer, 3 Super Admin
 }
 
 // == Webinar Data Interfaces ==
@@ -0,0 +1,91 @@
+// Session helper :
+
+// The access token only live for a few minutes (WRPL_ACCESS_TTL on the
+// backend), so every protected call go through authFetch. On a 401 it trade
+// the refresh token for a new pair on /api/token/refresh and retry once.
+
+import { API_URL } from "@/api/endpoint";
+
+interface SessionTokens {
+  token?: string;
+  refresh_token?: string;
+}
+
+export const saveSession = (result: SessionTokens) => {
+  if (result.token) {
+    localStorage.setItem("token", result.token);
+  }
+  if (result.refresh_token) {
+    localStorage.setItem("refresh_token", result.refresh_token);
+  }
+};
+
+export const clearSession = () => {
+  localStorage.removeItem("token");
+  localStorage.removeItem("refresh_token");
+};
+
+// The refresh token is rotated on every call, so parallel 401 must share the
+// same refresh or the second one would be rejected.
+let refreshing: Promise<boolean> | null = null;
+
+export const refreshSession = (): Promise<boolean> => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refresh = localStorage.getItem("refresh_token");
+      if (!refresh) {
+        return false;
+      }
+
+      try {
+        const response = await fetch(`${API_URL}/api/token/refresh`, {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ refresh_token: refresh }),
+        });
+        const result = await response.json();
+
+        if (result.success && result.token) {
+          saveSession(result);
+          return true;
+        }
+        // Expired or revoked, only a new login can help now
+        clearSession();
+        return false;
+      } catch (error) {
+        return false;
+      }
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// Same as fetch but with the current access token, refreshed once on 401.
+export const authFetch = async (
+  input: string,
+  init: RequestInit = {},
+): Promise<Response> => {
+  const send = () => {
+    const headers = new Headers(init.headers);
+    const token = localStorage.getItem("token");
+    if (token) {
+      headers.set("Authorization", `Bearer ${token}`);
+    }
+    return fetch(input, { ...init, headers });
+  };
+
+  const response = await send();
+  if (response.status !== 401) {
+    return response;
+  }
+
+  const refreshed = await refreshSession();
+  if (!refreshed) {
+    return response;
+  }
+  return send();
+};
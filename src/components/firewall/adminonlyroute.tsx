@@ -29,7 +29,7 @@ const AdminOnlyRoute = ({
         if (response.success && response.data) {
           const userData = response.data as UserData;
 
-          if (requireAdmin && userData.UserRole !== 1) {
+          if (requireAdmin && userData.UserRole !== 1 && userData.UserRole !== 3) {
             navigate("/");
           } else {
             setIsAuthorized(true);
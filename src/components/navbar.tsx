@@ -48,7 +48,7 @@ export const Navbar = () => {
         setEmail(userData.UserEmail);
         setProfilePicture(userData.UserPicture);
         setIsLoggedIn(true);
-        setIsAdmin(userData.UserRole === 1);
+        setIsAdmin(userData.UserRole === 1 || userData.UserRole === 3);
       } else {
         // Token invalid, clear it
         localStorage.removeItem("token");
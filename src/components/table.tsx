@@ -46,17 +46,23 @@ import { useNavigate } from "react-router-dom";
 
 // ===== CONSTANTS =====
 const USER_ROLES = {
+  USER: 0,
   ADMIN: 1,
-  USER: 2,
+  ORGANIZER: 2,
+  SUPER_ADMIN: 3,
 } as const;
 
 const ROLE_LABELS = {
-  [USER_ROLES.ADMIN]: "Admin",
   [USER_ROLES.USER]: "User",
+  [USER_ROLES.ADMIN]: "Admin",
+  [USER_ROLES.ORGANIZER]: "Organizer",
+  [USER_ROLES.SUPER_ADMIN]: "Super Admin",
 } as const;
 
 const ROLE_COLORS: Record<string, ChipProps["color"]> = {
+  "Super Admin": "danger",
   Admin: "primary",
+  Organizer: "warning",
   User: "success",
 };
 
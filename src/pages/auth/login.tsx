@@ -150,7 +150,7 @@ export default function LoginPage() {
     }
 
     try {
-      const response = await auth_otp.send_otp(forgotEmail);
+      const response = await auth_otp.send_otp(forgotEmail, "reset-password");
       if (response.success) {
         toast.success("OTP has been sent to your email.", {
           toastId: "c",